@@ -0,0 +1,337 @@
+package prismacloud
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/integration"
+	notification_template "github.com/paloaltonetworks/prisma-cloud-go/notification-template"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"github.com/paloaltonetworks/prisma-cloud-go/settings/enterprise"
+)
+
+/*
+Checks of an alert rule against the rest of the tenant.
+*/
+
+// notificationTemplateTypes are the config types that take a notification
+// template.
+var notificationTemplateTypes = []string{
+	rule.TypeEmail,
+	rule.TypeJira,
+	rule.TypeServiceNow,
+}
+
+// notificationLookup finds the integrations and notification templates an
+// alert rule refers to, asking for each one only once.
+type notificationLookup struct {
+	getIntegration func(id string) (integration.Integration, error)
+	getTemplate    func(id string) (notification_template.NotificationTemplate, error)
+
+	integrations map[string]*integration.Integration
+	templates    map[string]*notification_template.NotificationTemplate
+}
+
+func newNotificationLookup(client *pc.Client) *notificationLookup {
+	return &notificationLookup{
+		getIntegration: func(id string) (integration.Integration, error) {
+			var ans integration.Integration
+			var lastErr error
+			if diags := RetryWithBackoff(client, func() error {
+				var err error
+				ans, err = integration.Get(client, id, true)
+				lastErr = err
+				return err
+			}); diags != nil {
+				return ans, lastErr
+			}
+			return ans, nil
+		},
+		getTemplate: func(id string) (notification_template.NotificationTemplate, error) {
+			// This never errors, a missing template comes back empty.
+			return notification_template.Get(client, id)
+		},
+	}
+}
+
+// Integration returns the integration, or nil if it does not exist.
+func (o *notificationLookup) Integration(id string) (*integration.Integration, error) {
+	if o.integrations == nil {
+		o.integrations = make(map[string]*integration.Integration)
+	}
+	if ans, ok := o.integrations[id]; ok {
+		return ans, nil
+	}
+
+	var ans *integration.Integration
+	obj, err := o.getIntegration(id)
+	switch {
+	case err == nil:
+		ans = &obj
+	case err != pc.ObjectNotFoundError:
+		return nil, err
+	}
+
+	o.integrations[id] = ans
+	return ans, nil
+}
+
+// Template returns the notification template, or nil if it does not exist.
+func (o *notificationLookup) Template(id string) (*notification_template.NotificationTemplate, error) {
+	if o.templates == nil {
+		o.templates = make(map[string]*notification_template.NotificationTemplate)
+	}
+	if ans, ok := o.templates[id]; ok {
+		return ans, nil
+	}
+
+	var ans *notification_template.NotificationTemplate
+	obj, err := o.getTemplate(id)
+	switch {
+	case err != nil && err != pc.ObjectNotFoundError:
+		return nil, err
+	case err == nil && obj.Id != "":
+		ans = &obj
+	}
+
+	o.templates[id] = ans
+	return ans, nil
+}
+
+// checkNotificationChannels checks that a notification config's recipients
+// and template fit its config type.  Email recipients have to be email
+// addresses, Jira and ServiceNow recipients have to be notification templates
+// of the config type, and other recipients have to be integrations of the
+// config type.  Values that are not known yet are skipped, and so are
+// integrations and templates if lookup is nil or they can't be read.
+func checkNotificationChannels(lookup *notificationLookup, path string, nc cty.Value) []error {
+	if !nc.IsKnown() || nc.IsNull() {
+		return nil
+	}
+
+	v := nc.GetAttr("config_type")
+	if !v.IsKnown() || v.IsNull() || v.AsString() == "" {
+		return nil
+	}
+	configType := v.AsString()
+
+	var errs []error
+	if v := nc.GetAttr("recipients"); v.IsKnown() && !v.IsNull() {
+		for it := v.ElementIterator(); it.Next(); {
+			_, r := it.Element()
+			if !r.IsKnown() || r.IsNull() {
+				continue
+			}
+			recipient := r.AsString()
+
+			switch {
+			case configType == rule.TypeEmail:
+				if !isEmailAddress(recipient) {
+					errs = append(errs, fmt.Errorf("%s.recipients: %q is not an email address", path, recipient))
+				}
+			case isEmailAddress(recipient):
+				errs = append(errs, fmt.Errorf("%s.recipients: %q is an email address, but config_type %q needs %s IDs", path, recipient, configType, notificationRecipientKind(configType)))
+			case lookup == nil:
+			case stringInSlice(configType, notificationTemplateTypes):
+				if err := checkNotificationTemplate(lookup, configType, recipient); err != nil {
+					errs = append(errs, fmt.Errorf("%s.recipients: %s", path, err))
+				}
+			default:
+				obj, err := lookup.Integration(recipient)
+				switch {
+				case err != nil:
+					log.Printf("[WARN] Error getting integration %q, skipping its checks: %s", recipient, err)
+				case obj == nil:
+					errs = append(errs, fmt.Errorf("%s.recipients: integration %q does not exist", path, recipient))
+				case obj.IntegrationType != configType:
+					errs = append(errs, fmt.Errorf("%s.recipients: integration %q (%s) is of type %q, but config_type is %q", path, recipient, obj.Name, obj.IntegrationType, configType))
+				}
+			}
+		}
+	}
+
+	v = nc.GetAttr("template_id")
+	if !v.IsKnown() || v.IsNull() || v.AsString() == "" {
+		return errs
+	}
+	templateId := v.AsString()
+
+	if !stringInSlice(configType, notificationTemplateTypes) {
+		return append(errs, fmt.Errorf("%s.template_id: config_type %q does not take a template", path, configType))
+	}
+	if lookup == nil {
+		return errs
+	}
+	if err := checkNotificationTemplate(lookup, configType, templateId); err != nil {
+		errs = append(errs, fmt.Errorf("%s.template_id: %s", path, err))
+	}
+
+	return errs
+}
+
+// checkNotificationTemplate checks that the notification template exists and
+// is of the config type.  A template that can't be read is not checked.
+func checkNotificationTemplate(lookup *notificationLookup, configType, id string) error {
+	obj, err := lookup.Template(id)
+	switch {
+	case err != nil:
+		log.Printf("[WARN] Error getting notification template %q, skipping its checks: %s", id, err)
+	case obj == nil:
+		return fmt.Errorf("notification template %q does not exist", id)
+	case obj.IntegrationType != configType:
+		return fmt.Errorf("notification template %q (%s) is of type %q, but config_type is %q", id, obj.Name, obj.IntegrationType, configType)
+	}
+
+	return nil
+}
+
+func isEmailAddress(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// notificationRecipientKind is what the recipients of a config type are.
+func notificationRecipientKind(configType string) string {
+	if stringInSlice(configType, notificationTemplateTypes) {
+		return "notification template"
+	}
+
+	return "integration"
+}
+
+// alertRuleWarningNames is the most policy names listed in a warning.
+const alertRuleWarningNames = 10
+
+// tenantSettings are the tenant wide objects alert rules are checked against.
+// They are read at most once per run, no matter how many alert rules there
+// are.
+type tenantSettings struct {
+	enterpriseOnce sync.Once
+	enterprise     *enterprise.Config
+
+	policiesOnce sync.Once
+	policies     map[string]policy.Policy
+}
+
+// tenantSettingsCache holds the tenantSettings of each client.
+var tenantSettingsCache sync.Map
+
+func getTenantSettings(client *pc.Client) *tenantSettings {
+	v, _ := tenantSettingsCache.LoadOrStore(client, &tenantSettings{})
+	return v.(*tenantSettings)
+}
+
+// Enterprise returns the enterprise settings, or nil if they can't be read.
+func (o *tenantSettings) Enterprise(client *pc.Client) *enterprise.Config {
+	o.enterpriseOnce.Do(func() {
+		var conf enterprise.Config
+		var lastErr error
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			conf, err = enterprise.Get(client)
+			lastErr = err
+			return err
+		}); diags != nil {
+			log.Printf("[WARN] Error getting enterprise settings, skipping alert rule checks: %s", lastErr)
+			return
+		}
+		o.enterprise = &conf
+	})
+
+	return o.enterprise
+}
+
+// Policies returns the policies by ID, or nil if they can't be read.
+func (o *tenantSettings) Policies(client *pc.Client) map[string]policy.Policy {
+	o.policiesOnce.Do(func() {
+		var err error
+		if o.policies, err = alertPolicies(client); err != nil {
+			log.Printf("[WARN] Error getting policies, skipping alert rule checks: %s", err)
+		}
+	})
+
+	return o.policies
+}
+
+// alertRuleSettingsWarnings warns about alert rule params that don't do what
+// they say because of the tenant's settings.  Either conf or policies may be
+// nil if they could not be read.
+//
+// delay_notification_ms is not checked, as no enterprise setting affects it.
+func alertRuleSettingsWarnings(o rule.Rule, conf *enterprise.Config, policies map[string]policy.Policy) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var params []string
+	if o.NotifyOnDismissed {
+		params = append(params, "notify_on_dismissed")
+	}
+	if o.NotifyOnSnoozed {
+		params = append(params, "notify_on_snoozed")
+	}
+
+	if conf != nil && len(params) > 0 {
+		if !conf.UserAttributionInNotification {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "User attribution in notifications is off",
+				Detail: fmt.Sprintf(
+					"Alert rule %q has %s set, but the enterprise setting userAttributionInNotification is off, so its notifications won't say who dismissed or snoozed the alerts.",
+					o.Name, strings.Join(params, " and "),
+				),
+				AttributePath: cty.GetAttrPath(params[0]),
+			})
+		}
+		if !conf.RequireAlertDismissalNote {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Alert dismissal notes are optional",
+				Detail: fmt.Sprintf(
+					"Alert rule %q has %s set, but the enterprise setting requireAlertDismissalNote is off, so its notifications may not say why the alerts were dismissed or snoozed.",
+					o.Name, strings.Join(params, " and "),
+				),
+				AttributePath: cty.GetAttrPath(params[0]),
+			})
+		}
+	}
+
+	if o.AllowAutoRemediate && policies != nil {
+		var names []string
+		var total int
+		for _, p := range policies {
+			if p.Enabled && alertRuleCovers(o, p) {
+				total++
+				if !p.Remediable {
+					names = append(names, p.Name)
+				}
+			}
+		}
+
+		if len(names) > 0 {
+			sort.Strings(names)
+			list := names
+			if len(list) > alertRuleWarningNames {
+				list = append(list[:alertRuleWarningNames:alertRuleWarningNames], fmt.Sprintf("and %d more", len(names)-alertRuleWarningNames))
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Alert rule covers policies that can't be remediated",
+				Detail: fmt.Sprintf(
+					"Alert rule %q has allow_auto_remediate set, but %d of its %d enabled policies are not remediable, so their alerts won't be remediated: %s.",
+					o.Name, len(names), total, strings.Join(list, ", "),
+				),
+				AttributePath: cty.GetAttrPath("allow_auto_remediate"),
+			})
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,190 @@
+package prismacloud
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/integration"
+	notification_template "github.com/paloaltonetworks/prisma-cloud-go/notification-template"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"github.com/paloaltonetworks/prisma-cloud-go/settings/enterprise"
+)
+
+func TestCheckNotificationChannels(t *testing.T) {
+	integrations := map[string]integration.Integration{
+		"slack-1": {Id: "slack-1", Name: "Team channel", IntegrationType: "slack"},
+		"jira-1":  {Id: "jira-1", Name: "Jira", IntegrationType: "jira"},
+	}
+	templates := map[string]notification_template.NotificationTemplate{
+		"email-t": {Id: "email-t", Name: "Email", IntegrationType: "email"},
+		"jira-t":  {Id: "jira-t", Name: "Jira", IntegrationType: "jira", IntegrationId: "jira-1"},
+	}
+
+	var calls int
+	lookup := &notificationLookup{
+		getIntegration: func(id string) (integration.Integration, error) {
+			calls++
+			if obj, ok := integrations[id]; ok {
+				return obj, nil
+			}
+			if id == "locked" {
+				return integration.Integration{}, fmt.Errorf("403 forbidden")
+			}
+			return integration.Integration{}, pc.ObjectNotFoundError
+		},
+		getTemplate: func(id string) (notification_template.NotificationTemplate, error) {
+			if id == "locked-t" {
+				return notification_template.NotificationTemplate{}, fmt.Errorf("403 forbidden")
+			}
+			return templates[id], nil
+		},
+	}
+
+	nc := func(configType, templateId string, recipients ...cty.Value) cty.Value {
+		m := map[string]cty.Value{
+			"config_type": cty.StringVal(configType),
+			"template_id": cty.NullVal(cty.String),
+			"recipients":  cty.NullVal(cty.Set(cty.String)),
+		}
+		if templateId != "" {
+			m["template_id"] = cty.StringVal(templateId)
+		}
+		if len(recipients) > 0 {
+			m["recipients"] = cty.SetVal(recipients)
+		}
+		return cty.ObjectVal(m)
+	}
+	s := cty.StringVal
+
+	cases := []struct {
+		name string
+		nc   cty.Value
+		want []string
+	}{
+		{"email", nc("email", "email-t", s("a@example.com"), s("b@example.com")), nil},
+		{"bad email", nc("email", "", s("a@example.com"), s("Bob <b@example.com>"), s("nope")), []string{
+			`notification_config.0.recipients: "Bob <b@example.com>" is not an email address`,
+			`notification_config.0.recipients: "nope" is not an email address`,
+		}},
+		{"unknown recipient", nc("email", "", cty.UnknownVal(cty.String)), nil},
+		{"slack", nc("slack", "", s("slack-1")), nil},
+		{"email on slack", nc("slack", "", s("a@example.com")), []string{
+			`notification_config.0.recipients: "a@example.com" is an email address, but config_type "slack" needs integration IDs`,
+		}},
+		{"wrong integration type", nc("slack", "", s("jira-1")), []string{
+			`notification_config.0.recipients: integration "jira-1" (Jira) is of type "jira", but config_type is "slack"`,
+		}},
+		{"missing integration", nc("slack", "", s("gone")), []string{
+			`notification_config.0.recipients: integration "gone" does not exist`,
+		}},
+		{"unreadable integration", nc("slack", "", s("locked")), nil},
+		{"template on slack", nc("slack", "email-t", s("slack-1")), []string{
+			`notification_config.0.template_id: config_type "slack" does not take a template`,
+		}},
+		{"wrong template type", nc("email", "jira-t", s("a@example.com")), []string{
+			`notification_config.0.template_id: notification template "jira-t" (Jira) is of type "jira", but config_type is "email"`,
+		}},
+		{"missing template", nc("email", "gone", s("a@example.com")), []string{
+			`notification_config.0.template_id: notification template "gone" does not exist`,
+		}},
+		{"unreadable template", nc("email", "locked-t", s("a@example.com")), nil},
+		{"jira", nc("jira", "jira-t", s("jira-t")), nil},
+		{"jira integration as recipient", nc("jira", "", s("jira-1")), []string{
+			`notification_config.0.recipients: notification template "jira-1" does not exist`,
+		}},
+		{"email template as jira recipient", nc("jira", "", s("email-t")), []string{
+			`notification_config.0.recipients: notification template "email-t" (Email) is of type "email", but config_type is "jira"`,
+		}},
+		{"email on jira", nc("jira", "", s("a@example.com")), []string{
+			`notification_config.0.recipients: "a@example.com" is an email address, but config_type "jira" needs notification template IDs`,
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := checkNotificationChannels(lookup, "notification_config.0", tc.nc)
+			got := make([]string, 0, len(errs))
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("Got errors:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+
+	if calls != 4 {
+		t.Errorf("Integrations were looked up %d times, expected 4", calls)
+	}
+
+	// Without a lookup, only the values themselves are checked.
+	errs := checkNotificationChannels(nil, "notification_config.0", nc("slack", "email-t", s("gone"), s("a@example.com")))
+	got := make([]string, 0, len(errs))
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`notification_config.0.recipients: "a@example.com" is an email address, but config_type "slack" needs integration IDs`,
+		`notification_config.0.template_id: config_type "slack" does not take a template`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got errors without a lookup:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAlertRuleSettingsWarnings(t *testing.T) {
+	conf := &enterprise.Config{
+		UserAttributionInNotification: true,
+		RequireAlertDismissalNote:     true,
+	}
+	policies := map[string]policy.Policy{
+		"p1": {PolicyId: "p1", Name: "Open bucket", Enabled: true, Remediable: true},
+		"p2": {PolicyId: "p2", Name: "Root login", Enabled: true},
+		"p3": {PolicyId: "p3", Name: "Old policy"},
+	}
+
+	cases := []struct {
+		name     string
+		rule     rule.Rule
+		conf     *enterprise.Config
+		policies map[string]policy.Policy
+		want     []string
+	}{
+		{"nothing", rule.Rule{Name: "r", NotifyOnDismissed: true}, conf, policies, nil},
+		{"no attribution", rule.Rule{Name: "r", NotifyOnDismissed: true, NotifyOnSnoozed: true}, &enterprise.Config{RequireAlertDismissalNote: true}, nil, []string{
+			`Alert rule "r" has notify_on_dismissed and notify_on_snoozed set, but the enterprise setting userAttributionInNotification is off, so its notifications won't say who dismissed or snoozed the alerts.`,
+		}},
+		{"no dismissal note", rule.Rule{Name: "r", NotifyOnSnoozed: true}, &enterprise.Config{UserAttributionInNotification: true}, nil, []string{
+			`Alert rule "r" has notify_on_snoozed set, but the enterprise setting requireAlertDismissalNote is off, so its notifications may not say why the alerts were dismissed or snoozed.`,
+		}},
+		{"settings unknown", rule.Rule{Name: "r", NotifyOnSnoozed: true}, nil, nil, nil},
+		{"not notifying", rule.Rule{Name: "r"}, &enterprise.Config{}, nil, nil},
+		{"remediable", rule.Rule{Name: "r", AllowAutoRemediate: true, Policies: []string{"p1", "p3"}}, conf, policies, nil},
+		{"not remediable", rule.Rule{Name: "r", AllowAutoRemediate: true, ScanAll: true}, conf, policies, []string{
+			`Alert rule "r" has allow_auto_remediate set, but 1 of its 2 enabled policies are not remediable, so their alerts won't be remediated: Root login.`,
+		}},
+		{"policies unknown", rule.Rule{Name: "r", AllowAutoRemediate: true, ScanAll: true}, conf, nil, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := alertRuleSettingsWarnings(tc.rule, tc.conf, tc.policies)
+			got := make([]string, 0, len(diags))
+			for _, d := range diags {
+				if d.Severity != diag.Warning {
+					t.Errorf("%q is not a warning", d.Summary)
+				}
+				got = append(got, d.Detail)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("Got warnings:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package prismacloud
+
+import (
+	"fmt"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+	"github.com/paloaltonetworks/prisma-cloud-go/timerange"
+)
+
+/*
+The alert package can only list and get alerts, so these are the calls to
+change an alert's status.  They are built on Communicate like the SDK's own
+functions, but live here as the SDK is vendored, not changed by the provider.
+*/
+
+// Alert statuses.
+const (
+	alertStatusOpen      = "open"
+	alertStatusDismissed = "dismissed"
+	alertStatusSnoozed   = "snoozed"
+	alertStatusResolved  = "resolved"
+)
+
+// alertStatusBatchSize is the most alerts changed or looked up at once.
+const alertStatusBatchSize = 100
+
+// alertStatusRequest is the request body to dismiss, snooze or reopen alerts.
+type alertStatusRequest struct {
+	Alerts             []string             `json:"alerts"`
+	Policies           []string             `json:"policies"`
+	DismissalNote      string               `json:"dismissalNote,omitempty"`
+	DismissalTimeRange *timerange.TimeRange `json:"dismissalTimeRange,omitempty"`
+	Filter             alertStatusFilter    `json:"filter"`
+}
+
+type alertStatusFilter struct {
+	TimeRange timerange.TimeRange `json:"timeRange"`
+}
+
+// allAlertsTimeRange covers all alerts, no matter when they were raised.
+func allAlertsTimeRange() timerange.TimeRange {
+	return timerange.TimeRange{
+		Type:  timerange.TypeToNow,
+		Value: timerange.Epoch,
+	}
+}
+
+// dismissAlerts dismisses the alerts with the given note.  If until is not
+// zero, the alerts are snoozed until then (in ms since the epoch) instead.
+func dismissAlerts(c pc.PrismaCloudClient, ids []string, note string, now, until int) error {
+	req := alertStatusRequest{
+		DismissalNote: note,
+		Filter:        alertStatusFilter{TimeRange: allAlertsTimeRange()},
+	}
+	if until != 0 {
+		req.DismissalTimeRange = &timerange.TimeRange{
+			Type:  timerange.TypeAbsolute,
+			Value: timerange.Absolute{Start: now, End: until},
+		}
+	}
+
+	return changeAlertStatus(c, "dismiss", ids, req)
+}
+
+// reopenAlerts reopens dismissed or snoozed alerts.
+func reopenAlerts(c pc.PrismaCloudClient, ids []string) error {
+	return changeAlertStatus(c, "reopen", ids, alertStatusRequest{
+		Filter: alertStatusFilter{TimeRange: allAlertsTimeRange()},
+	})
+}
+
+func changeAlertStatus(c pc.PrismaCloudClient, action string, ids []string, req alertStatusRequest) error {
+	req.Policies = []string{}
+
+	for len(ids) > 0 {
+		n := min(len(ids), alertStatusBatchSize)
+		req.Alerts = ids[:n]
+		ids = ids[n:]
+
+		c.Log(pc.LogAction, "(%s) %d alerts", action, len(req.Alerts))
+		if _, err := c.Communicate("POST", []string{"alert", action}, nil, req, nil); err != nil {
+			return fmt.Errorf("%s alerts: %w", action, err)
+		}
+	}
+
+	return nil
+}
+
+// getAlertStatuses returns the status of each alert that still exists.
+func getAlertStatuses(client *pc.Client, ids []string) (map[string]string, error) {
+	ans := make(map[string]string, len(ids))
+
+	for len(ids) > 0 {
+		n := min(len(ids), alertStatusBatchSize)
+		req := alert.Request{
+			TimeRange: allAlertsTimeRange(),
+			Filters:   make([]alert.Filter, 0, n),
+		}
+		for _, id := range ids[:n] {
+			req.Filters = append(req.Filters, alert.Filter{
+				Name:     "alert.id",
+				Operator: "=",
+				Value:    id,
+			})
+		}
+		ids = ids[n:]
+
+		if _, err := listAlerts(client, req, n, func(list []alert.Alert) error {
+			for _, a := range list {
+				ans[a.Id] = a.Status
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return ans, nil
+}
+
+// alertResourceTags returns the tags of the alert's resource.
+func alertResourceTags(a alert.Alert) map[string]string {
+	return resourceTags(a.Resource.Tags)
+}
+
+// resourceTags returns the tags in a resource's JSON, which are either a map
+// or a list of key/value objects.
+func resourceTags(tags interface{}) map[string]string {
+	ans := make(map[string]string)
+	switch x := tags.(type) {
+	case map[string]interface{}:
+		for key, value := range x {
+			ans[key] = fmt.Sprint(value)
+		}
+	case []interface{}:
+		// Some resources list their tags as key/value objects.
+		for _, v := range x {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if key, ok := m["key"]; ok {
+				ans[fmt.Sprint(key)] = fmt.Sprint(m["value"])
+			} else if key, ok := m["Key"]; ok {
+				ans[fmt.Sprint(key)] = fmt.Sprint(m["Value"])
+			}
+		}
+	}
+
+	return ans
+}
+
+// alertHasTags returns if the alert's resource has all of the given tags.
+func alertHasTags(a alert.Alert, tags map[string]string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+
+	have := alertResourceTags(a)
+	for key, value := range tags {
+		if v, ok := have[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
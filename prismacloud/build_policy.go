@@ -0,0 +1,639 @@
+package prismacloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Build policies carry their definition as a Checkov style YAML document in the
+child's metadata "code".  A definition is either a single condition:
+
+	cond_type: attribute
+	resource_types:
+	- aws_s3_bucket
+	attribute: acl
+	operator: not_equals
+	value: public-read
+
+or an "and" / "or" list of definitions, nested as deep as needed.  The
+document may also be wrapped in a top level "definition" key, alongside
+Checkov's "metadata" and "scope" keys.
+
+The parser below only checks the structure, it does not know which attributes
+exist on which resource types.
+*/
+
+const (
+	buildCondAttribute  = "attribute"
+	buildCondConnection = "connection"
+	buildCondFilter     = "filter"
+)
+
+// buildAttributeOperators are the operators of an attribute condition.  Most
+// of them may also be prefixed with "jsonpath_".
+var buildAttributeOperators = map[string]bool{
+	"equals":                                true,
+	"not_equals":                            true,
+	"equals_ignore_case":                    true,
+	"not_equals_ignore_case":                true,
+	"regex_match":                           true,
+	"not_regex_match":                       true,
+	"exists":                                true,
+	"not_exists":                            true,
+	"one_exists":                            true,
+	"any":                                   true,
+	"contains":                              true,
+	"not_contains":                          true,
+	"within":                                true,
+	"not_within":                            true,
+	"starting_with":                         true,
+	"not_starting_with":                     true,
+	"ending_with":                           true,
+	"not_ending_with":                       true,
+	"greater_than":                          true,
+	"greater_than_or_equal":                 true,
+	"less_than":                             true,
+	"less_than_or_equal":                    true,
+	"subset":                                true,
+	"not_subset":                            true,
+	"intersects":                            true,
+	"not_intersects":                        true,
+	"is_empty":                              true,
+	"is_not_empty":                          true,
+	"is_true":                               true,
+	"is_false":                              true,
+	"is_sorted":                             true,
+	"length_equals":                         true,
+	"length_not_equals":                     true,
+	"length_greater_than":                   true,
+	"length_greater_than_or_equal":          true,
+	"length_less_than":                      true,
+	"length_less_than_or_equal":             true,
+	"number_of_words_equals":                true,
+	"number_of_words_not_equals":            true,
+	"number_of_words_greater_than":          true,
+	"number_of_words_greater_than_or_equal": true,
+	"number_of_words_less_than":             true,
+	"number_of_words_less_than_or_equal":    true,
+	"range_includes":                        true,
+	"range_not_includes":                    true,
+	"cidr_range_subset":                     true,
+	"cidr_range_not_subset":                 true,
+}
+
+// buildValuelessOperators are the attribute operators that take no value.
+var buildValuelessOperators = map[string]bool{
+	"exists":       true,
+	"not_exists":   true,
+	"one_exists":   true,
+	"is_empty":     true,
+	"is_not_empty": true,
+	"is_true":      true,
+	"is_false":     true,
+	"is_sorted":    true,
+}
+
+// buildConditionKeys are the keys allowed in a single condition.
+var buildConditionKeys = map[string]bool{
+	"cond_type":                true,
+	"resource_types":           true,
+	"connected_resource_types": true,
+	"attribute":                true,
+	"operator":                 true,
+	"value":                    true,
+}
+
+// buildPolicyCondition is a single (leaf) condition of a build policy.  Path
+// locates it in the definition, such as "and[1].or[0]".
+type buildPolicyCondition struct {
+	Path                   string
+	CondType               string
+	ResourceTypes          []string
+	ConnectedResourceTypes []string
+	Attribute              string
+	Operator               string
+	Value                  interface{}
+}
+
+// buildPolicyError is a problem with a build policy definition.
+type buildPolicyError struct {
+	line int
+	path string
+	msg  string
+}
+
+func (e *buildPolicyError) Error() string {
+	if e.path == "" {
+		return fmt.Sprintf("build policy definition, line %d: %s", e.line, e.msg)
+	}
+	return fmt.Sprintf("build policy definition, line %d: %s: %s", e.line, e.path, e.msg)
+}
+
+// parseBuildPolicy parses and checks a build policy definition, returning its
+// conditions in document order.
+func parseBuildPolicy(code string) ([]buildPolicyCondition, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(code), &doc); err != nil {
+		return nil, fmt.Errorf("build policy definition is not valid YAML: %s", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("build policy definition is empty")
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &buildPolicyError{root.Line, "", "expected a mapping"}
+	}
+
+	if def := yamlMappingValue(root, "definition"); def != nil {
+		for i := 0; i < len(root.Content); i += 2 {
+			switch key := root.Content[i]; key.Value {
+			case "definition", "metadata", "scope":
+			default:
+				return nil, &buildPolicyError{key.Line, "", fmt.Sprintf("unknown key %q", key.Value)}
+			}
+		}
+		root = def
+	}
+
+	var ans []buildPolicyCondition
+	if err := parseBuildPolicyNode(root, "", &ans); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func parseBuildPolicyNode(node *yaml.Node, path string, ans *[]buildPolicyCondition) error {
+	if node.Kind != yaml.MappingNode {
+		return &buildPolicyError{node.Line, path, "expected a condition or an and/or list"}
+	}
+
+	for _, op := range []string{"and", "or"} {
+		list := yamlMappingValue(node, op)
+		if list == nil {
+			continue
+		}
+		if len(node.Content) != 2 {
+			return &buildPolicyError{node.Line, path, fmt.Sprintf("%q must be the only key of its mapping", op)}
+		}
+		if list.Kind != yaml.SequenceNode || len(list.Content) == 0 {
+			return &buildPolicyError{list.Line, joinBuildPath(path, op), "expected a non-empty list"}
+		}
+		for i, item := range list.Content {
+			if err := parseBuildPolicyNode(item, fmt.Sprintf("%s[%d]", joinBuildPath(path, op), i), ans); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cond, err := parseBuildPolicyCondition(node, path)
+	if err != nil {
+		return err
+	}
+	*ans = append(*ans, cond)
+
+	return nil
+}
+
+func parseBuildPolicyCondition(node *yaml.Node, path string) (buildPolicyCondition, error) {
+	ans := buildPolicyCondition{Path: path}
+	fail := func(n *yaml.Node, key, msg string) (buildPolicyCondition, error) {
+		return ans, &buildPolicyError{n.Line, joinBuildPath(path, key), msg}
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if key := node.Content[i]; !buildConditionKeys[key.Value] {
+			return fail(key, "", fmt.Sprintf("unknown key %q", key.Value))
+		}
+	}
+
+	var err error
+	if ans.CondType, err = yamlMappingString(node, "cond_type"); err != nil {
+		return fail(node, "cond_type", err.Error())
+	}
+	if ans.ResourceTypes, err = yamlMappingStrings(node, "resource_types"); err != nil {
+		return fail(node, "resource_types", err.Error())
+	}
+	if ans.ConnectedResourceTypes, err = yamlMappingStrings(node, "connected_resource_types"); err != nil {
+		return fail(node, "connected_resource_types", err.Error())
+	}
+	if ans.Attribute, err = yamlMappingString(node, "attribute"); err != nil {
+		return fail(node, "attribute", err.Error())
+	}
+	if ans.Operator, err = yamlMappingString(node, "operator"); err != nil {
+		return fail(node, "operator", err.Error())
+	}
+	value := yamlMappingValue(node, "value")
+	if value != nil {
+		if err = value.Decode(&ans.Value); err != nil {
+			return fail(value, "value", err.Error())
+		}
+	}
+
+	if ans.Operator == "" {
+		return fail(node, "operator", "is required")
+	}
+
+	switch ans.CondType {
+	case "":
+		return fail(node, "cond_type", "is required")
+	case buildCondAttribute:
+		if len(ans.ResourceTypes) == 0 {
+			return fail(node, "resource_types", "is required")
+		}
+		if ans.Attribute == "" {
+			return fail(node, "attribute", "is required")
+		}
+		if len(ans.ConnectedResourceTypes) != 0 {
+			return fail(node, "connected_resource_types", "is only valid for a connection condition")
+		}
+		op := strings.TrimPrefix(ans.Operator, "jsonpath_")
+		if !buildAttributeOperators[op] {
+			return fail(node, "operator", fmt.Sprintf("unknown operator %q", ans.Operator))
+		}
+		if value == nil && !buildValuelessOperators[op] {
+			return fail(node, "value", fmt.Sprintf("is required for operator %q", ans.Operator))
+		}
+	case buildCondConnection:
+		if len(ans.ResourceTypes) == 0 {
+			return fail(node, "resource_types", "is required")
+		}
+		if len(ans.ConnectedResourceTypes) == 0 {
+			return fail(node, "connected_resource_types", "is required")
+		}
+		if ans.Operator != "exists" && ans.Operator != "not_exists" {
+			return fail(node, "operator", fmt.Sprintf("must be \"exists\" or \"not_exists\" for a connection condition, got %q", ans.Operator))
+		}
+		if ans.Attribute != "" || value != nil {
+			return fail(node, "", "a connection condition takes no attribute or value")
+		}
+	case buildCondFilter:
+		if ans.Attribute != "resource_type" {
+			return fail(node, "attribute", "must be \"resource_type\" for a filter condition")
+		}
+		if ans.Operator != "within" {
+			return fail(node, "operator", "must be \"within\" for a filter condition")
+		}
+		if value == nil {
+			return fail(node, "value", "is required")
+		}
+	default:
+		return fail(node, "cond_type", fmt.Sprintf("must be one of %q, %q, or %q, got %q", buildCondAttribute, buildCondConnection, buildCondFilter, ans.CondType))
+	}
+
+	return ans, nil
+}
+
+func joinBuildPath(path, key string) string {
+	switch {
+	case path == "":
+		return key
+	case key == "":
+		return path
+	}
+	return path + "." + key
+}
+
+// yamlMappingValue returns the value of key in a mapping node, or nil.
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func yamlMappingString(node *yaml.Node, key string) (string, error) {
+	v := yamlMappingValue(node, key)
+	if v == nil {
+		return "", nil
+	}
+	if v.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf("expected a string")
+	}
+	return v.Value, nil
+}
+
+// yamlMappingStrings returns a string or list of strings.
+func yamlMappingStrings(node *yaml.Node, key string) ([]string, error) {
+	v := yamlMappingValue(node, key)
+	if v == nil {
+		return nil, nil
+	}
+
+	switch v.Kind {
+	case yaml.ScalarNode:
+		return []string{v.Value}, nil
+	case yaml.SequenceNode:
+		ans := make([]string, 0, len(v.Content))
+		for _, item := range v.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("expected a list of strings")
+			}
+			ans = append(ans, item.Value)
+		}
+		return ans, nil
+	}
+
+	return nil, fmt.Errorf("expected a string or a list of strings")
+}
+
+// validateBuildPolicyMetadata is a ValidateFunc for a child's metadata.
+func validateBuildPolicyMetadata(v interface{}, k string) (ws []string, es []error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for key := range m {
+		if key != "code" {
+			es = append(es, fmt.Errorf("%s: unknown key %q, the only valid key is \"code\"", k, key))
+		}
+	}
+
+	if code, _ := m["code"].(string); strings.TrimSpace(code) != "" {
+		if _, err := parseBuildPolicy(code); err != nil {
+			es = append(es, fmt.Errorf("%s: %s", k, err))
+		}
+	}
+
+	return
+}
+
+// buildPolicyConditionsSchema is the schema of a child's parsed definition.
+// If optional, the conditions may be given instead of the metadata.
+func buildPolicyConditionsSchema(optional bool) *schema.Schema {
+	field := func(s *schema.Schema) *schema.Schema {
+		s.Optional = optional
+		s.Computed = !optional
+		return s
+	}
+
+	ans := &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    optional,
+		Computed:    true,
+		Description: "The conditions of the build policy definition in metadata",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"path": field(&schema.Schema{
+					Type:        schema.TypeString,
+					Description: "Location of the condition in the definition",
+				}),
+				"cond_type": field(&schema.Schema{
+					Type:        schema.TypeString,
+					Description: "Condition type",
+				}),
+				"resource_types": field(&schema.Schema{
+					Type:        schema.TypeList,
+					Description: "Resource types",
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+				}),
+				"connected_resource_types": field(&schema.Schema{
+					Type:        schema.TypeList,
+					Description: "Connected resource types",
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+				}),
+				"attribute": field(&schema.Schema{
+					Type:        schema.TypeString,
+					Description: "Attribute",
+				}),
+				"operator": field(&schema.Schema{
+					Type:        schema.TypeString,
+					Description: "Operator",
+				}),
+				"value": field(&schema.Schema{
+					Type:        schema.TypeString,
+					Description: "Value, as JSON",
+				}),
+			},
+		},
+	}
+
+	if optional {
+		ans.Description = "The conditions of the build policy definition, given instead of metadata or computed from it"
+		value := ans.Elem.(*schema.Resource).Schema["value"]
+		value.ValidateFunc = validation.StringIsJSON
+		value.DiffSuppressFunc = suppressEquivalentJsonDiffs
+	}
+
+	return ans
+}
+
+// flattenBuildPolicyConditions returns the "conditions" of a child.  Code that
+// does not parse has no conditions.
+func flattenBuildPolicyConditions(code string) []interface{} {
+	if strings.TrimSpace(code) == "" {
+		return nil
+	}
+
+	conds, err := parseBuildPolicy(code)
+	if err != nil {
+		log.Printf("[WARN] Not flattening build policy definition: %s", err)
+		return nil
+	}
+
+	ans := make([]interface{}, 0, len(conds))
+	for _, c := range conds {
+		var value string
+		if c.Value != nil {
+			b, err := json.Marshal(c.Value)
+			if err != nil {
+				log.Printf("[WARN] Failed to marshal value of %q: %s", c.Path, err)
+			}
+			value = string(b)
+		}
+
+		ans = append(ans, map[string]interface{}{
+			"path":                     c.Path,
+			"cond_type":                c.CondType,
+			"resource_types":           c.ResourceTypes,
+			"connected_resource_types": c.ConnectedResourceTypes,
+			"attribute":                c.Attribute,
+			"operator":                 c.Operator,
+			"value":                    value,
+		})
+	}
+
+	return ans
+}
+
+// policyChildHas reports whether the config of the i'th child of the rule
+// has key.
+func policyChildHas(raw cty.Value, i int, key string) bool {
+	v, err := cty.GetAttrPath("rule").IndexInt(0).GetAttr("children").IndexInt(i).GetAttr(key).Apply(raw)
+	if err != nil || !v.IsKnown() || v.IsNull() {
+		return false
+	}
+
+	return !v.Type().IsCollectionType() || v.LengthInt() != 0
+}
+
+// buildPolicyTree is an and / or list or a single condition of a definition
+// that is being put together from its conditions.
+type buildPolicyTree struct {
+	op    string
+	items []*buildPolicyTree
+	cond  *yaml.Node
+}
+
+var buildPolicyPathStep = regexp.MustCompile(`^(and|or)\[(\d+)\]$`)
+
+// insert places cond at path, the rest of the condition's full path.
+func (t *buildPolicyTree) insert(full, path string, cond *yaml.Node) error {
+	if path == "" {
+		if t.cond != nil || t.op != "" {
+			return fmt.Errorf("path %q is used by more than one condition or contains other conditions", full)
+		}
+		t.cond = cond
+		return nil
+	}
+
+	step, rest, _ := strings.Cut(path, ".")
+	m := buildPolicyPathStep.FindStringSubmatch(step)
+	if m == nil {
+		return fmt.Errorf("path %q: expected steps such as \"and[0]\" or \"or[1]\", separated by \".\"", full)
+	}
+	if t.cond != nil || (t.op != "" && t.op != m[1]) {
+		return fmt.Errorf("path %q clashes with another condition", full)
+	}
+	t.op = m[1]
+
+	i, err := strconv.Atoi(m[2])
+	if err != nil || i > 1000 {
+		return fmt.Errorf("path %q: bad index %q", full, m[2])
+	}
+	for len(t.items) <= i {
+		t.items = append(t.items, nil)
+	}
+	if t.items[i] == nil {
+		t.items[i] = &buildPolicyTree{}
+	}
+
+	return t.items[i].insert(full, rest, cond)
+}
+
+func (t *buildPolicyTree) node(path string) (*yaml.Node, error) {
+	if t.cond != nil {
+		return t.cond, nil
+	}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for i, item := range t.items {
+		p := fmt.Sprintf("%s[%d]", joinBuildPath(path, t.op), i)
+		if item == nil {
+			return nil, fmt.Errorf("there is no condition at %q", p)
+		}
+		n, err := item.node(p)
+		if err != nil {
+			return nil, err
+		}
+		list.Content = append(list.Content, n)
+	}
+
+	return &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: t.op}, list},
+	}, nil
+}
+
+// buildPolicyConditionNode returns the YAML of a flattened condition.
+func buildPolicyConditionNode(m map[string]interface{}) (*yaml.Node, error) {
+	ans := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, v interface{}) error {
+		var n yaml.Node
+		if err := n.Encode(v); err != nil {
+			return err
+		}
+		ans.Content = append(ans.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &n)
+		return nil
+	}
+
+	for _, key := range []string{"cond_type", "resource_types", "connected_resource_types", "attribute", "operator", "value"} {
+		var err error
+		switch v := m[key].(type) {
+		case string:
+			switch {
+			case v == "":
+			case key == "value":
+				var value interface{}
+				if err = json.Unmarshal([]byte(v), &value); err != nil {
+					return nil, fmt.Errorf("value is not JSON: %s", err)
+				}
+				err = add(key, value)
+			default:
+				err = add(key, v)
+			}
+		case []interface{}:
+			if len(v) != 0 {
+				err = add(key, ListToStringSlice(v))
+			}
+		case []string:
+			if len(v) != 0 {
+				err = add(key, v)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ans, nil
+}
+
+// buildPolicyCode returns the definition of the given conditions, which are
+// as flattened by flattenBuildPolicyConditions: the path of each condition
+// places it in the and / or lists.
+func buildPolicyCode(list []interface{}) (string, error) {
+	if len(list) == 0 {
+		return "", fmt.Errorf("no conditions given")
+	}
+
+	root := &buildPolicyTree{}
+	for _, x := range list {
+		m, _ := x.(map[string]interface{})
+		if m == nil {
+			return "", fmt.Errorf("empty condition")
+		}
+		cond, err := buildPolicyConditionNode(m)
+		if err != nil {
+			return "", fmt.Errorf("condition %q: %s", m["path"], err)
+		}
+		if err := root.insert(m["path"].(string), m["path"].(string), cond); err != nil {
+			return "", err
+		}
+	}
+
+	node, err := root.node("")
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(node)
+	if err != nil {
+		return "", err
+	}
+
+	code := string(b)
+	if _, err := parseBuildPolicy(code); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
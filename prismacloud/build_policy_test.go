@@ -0,0 +1,380 @@
+package prismacloud
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testBuildPolicyCode = `and:
+  - cond_type: filter
+    attribute: resource_type
+    operator: within
+    value:
+      - aws_s3_bucket
+  - or:
+      - cond_type: attribute
+        resource_types:
+          - aws_s3_bucket
+        attribute: acl
+        operator: not_equals
+        value: public-read
+      - cond_type: attribute
+        resource_types: all
+        attribute: tags.owner
+        operator: exists
+  - cond_type: connection
+    resource_types:
+      - aws_s3_bucket
+    connected_resource_types:
+      - aws_s3_bucket_public_access_block
+    operator: exists
+`
+
+func TestParseBuildPolicy(t *testing.T) {
+	conds, err := parseBuildPolicy(testBuildPolicyCode)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []buildPolicyCondition{
+		{
+			Path:      "and[0]",
+			CondType:  "filter",
+			Attribute: "resource_type",
+			Operator:  "within",
+			Value:     []interface{}{"aws_s3_bucket"},
+		},
+		{
+			Path:          "and[1].or[0]",
+			CondType:      "attribute",
+			ResourceTypes: []string{"aws_s3_bucket"},
+			Attribute:     "acl",
+			Operator:      "not_equals",
+			Value:         "public-read",
+		},
+		{
+			Path:          "and[1].or[1]",
+			CondType:      "attribute",
+			ResourceTypes: []string{"all"},
+			Attribute:     "tags.owner",
+			Operator:      "exists",
+		},
+		{
+			Path:                   "and[2]",
+			CondType:               "connection",
+			ResourceTypes:          []string{"aws_s3_bucket"},
+			ConnectedResourceTypes: []string{"aws_s3_bucket_public_access_block"},
+			Operator:               "exists",
+		},
+	}
+
+	if !reflect.DeepEqual(conds, want) {
+		t.Fatalf("Got %#v, expected %#v", conds, want)
+	}
+}
+
+func TestParseBuildPolicyValid(t *testing.T) {
+	cases := []string{
+		"cond_type: attribute\nresource_types: [aws_instance]\nattribute: monitoring\noperator: is_true\n",
+		"cond_type: attribute\nresource_types: [aws_instance]\nattribute: tags\noperator: jsonpath_exists\n",
+		"cond_type: attribute\nresource_types: [aws_instance]\nattribute: ebs_block_device[*].encrypted\noperator: jsonpath_equals\nvalue: true\n",
+		"definition:\n  cond_type: attribute\n  resource_types: [aws_instance]\n  attribute: ami\n  operator: regex_match\n  value: ^ami-\nmetadata:\n  name: x\nscope:\n  provider: aws\n",
+		"or: [{cond_type: attribute, resource_types: [a], attribute: b, operator: equals, value: 1}]\n",
+	}
+
+	for _, code := range cases {
+		if _, err := parseBuildPolicy(code); err != nil {
+			t.Errorf("%q: unexpected error: %s", code, err)
+		}
+	}
+}
+
+func TestParseBuildPolicyInvalid(t *testing.T) {
+	cases := []struct {
+		code string
+		want string
+	}{
+		{"and: [", "not valid YAML"},
+		{"", "empty"},
+		{"- a\n- b\n", "line 1: expected a mapping"},
+		{"and: []\n", "line 1: and: expected a non-empty list"},
+		{"and: [{cond_type: attribute}]\nor: []\n", `"and" must be the only key`},
+		{"and:\n  - cond_type: attribute\n    resource_types: [a]\n    attribute: b\n", "line 2: and[0].operator: is required"},
+		{"cond_type: attribute\nresource_types: [a]\nattribute: b\noperator: equal\nvalue: c\n", `operator: unknown operator "equal"`},
+		{"cond_type: attribute\nresource_types: [a]\nattribute: b\noperator: equals\n", `value: is required for operator "equals"`},
+		{"cond_type: attribute\nattribute: b\noperator: exists\n", "resource_types: is required"},
+		{"cond_type: attribute\nresource_types: [a]\noperator: exists\n", "attribute: is required"},
+		{"cond_type: attributes\nresource_types: [a]\nattribute: b\noperator: exists\n", `cond_type: must be one of`},
+		{"resource_types: [a]\nattribute: b\noperator: exists\n", "cond_type: is required"},
+		{"cond_type: attribute\nresource_types: [a]\nattribute: b\noperator: exists\nvalu: 1\n", `line 5: unknown key "valu"`},
+		{"cond_type: attribute\nresource_types: [[a]]\nattribute: b\noperator: exists\n", "resource_types: expected a list of strings"},
+		{"cond_type: connection\nresource_types: [a]\noperator: exists\n", "connected_resource_types: is required"},
+		{"cond_type: connection\nresource_types: [a]\nconnected_resource_types: [b]\noperator: equals\n", `must be "exists" or "not_exists"`},
+		{"cond_type: filter\nattribute: resource_type\noperator: equals\nvalue: [a]\n", `must be "within"`},
+		{"definition:\n  cond_type: filter\nextra: 1\n", `line 3: unknown key "extra"`},
+		{"or:\n  - and:\n      - 1\n", "line 3: or[0].and[0]: expected a condition"},
+	}
+
+	for _, tc := range cases {
+		_, err := parseBuildPolicy(tc.code)
+		if err == nil {
+			t.Errorf("%q: expected an error", tc.code)
+		} else if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: got %q, expected it to contain %q", tc.code, err, tc.want)
+		}
+	}
+}
+
+func TestValidateBuildPolicyMetadata(t *testing.T) {
+	if _, es := validateBuildPolicyMetadata(map[string]interface{}{"code": testBuildPolicyCode}, "metadata"); len(es) != 0 {
+		t.Errorf("Unexpected errors: %v", es)
+	}
+	if _, es := validateBuildPolicyMetadata(map[string]interface{}{"cod": testBuildPolicyCode}, "metadata"); len(es) != 1 {
+		t.Errorf("Expected one error for an unknown key, got %v", es)
+	}
+	if _, es := validateBuildPolicyMetadata(map[string]interface{}{"code": "and: []"}, "metadata"); len(es) != 1 {
+		t.Errorf("Expected one error for a bad definition, got %v", es)
+	}
+}
+
+func testBuildPolicyConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"name":            "build policy",
+		"policy_type":     "config",
+		"cloud_type":      "aws",
+		"severity":        "low",
+		"policy_subtypes": []interface{}{"build"},
+		"rule": []interface{}{
+			map[string]interface{}{
+				"name":      "build policy",
+				"rule_type": "Config",
+				"parameters": map[string]interface{}{
+					"savedSearch": "false",
+					"withIac":     "true",
+				},
+				"children": []interface{}{
+					map[string]interface{}{
+						"type":           "build",
+						"recommendation": "fix it",
+						"metadata": map[string]interface{}{
+							"code": testBuildPolicyCode,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPolicyBuildChildrenRoundTrip(t *testing.T) {
+	r := resourcePolicy()
+	raw := testBuildPolicyConfig()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, raw)
+	obj, diags := parsePolicy(d, "")
+	if diags.HasError() {
+		t.Fatalf("Unexpected diagnostics: %v", diags)
+	}
+	if len(obj.Rule.Children) != 1 || obj.Rule.Children[0].Metadata.Code != testBuildPolicyCode {
+		t.Fatalf("Children not parsed: %#v", obj.Rule.Children)
+	}
+
+	// The API hands the definition back re-serialized.
+	code, err := normalizeYaml(testBuildPolicyCode)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	obj.PolicyId = "1234"
+	obj.Rule.Children[0].Metadata.Code = code
+
+	d.SetId(obj.PolicyId)
+	savePolicy(d, obj, nil)
+
+	if got := d.Get("rule.0.children.0.conditions.#").(int); got != 4 {
+		t.Errorf("Got %d conditions, expected 4", got)
+	}
+	if got := d.Get("rule.0.children.0.conditions.1.value").(string); got != `"public-read"` {
+		t.Errorf("Got value %q, expected %q", got, `"public-read"`)
+	}
+
+	diff, err := r.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if diff != nil && len(diff.Attributes) != 0 {
+		for k, v := range diff.Attributes {
+			t.Errorf("Unexpected diff on %s: %q => %q", k, v.Old, v.New)
+		}
+	}
+}
+
+func TestParsePolicyBadMetadata(t *testing.T) {
+	raw := testBuildPolicyConfig()
+	child := raw["rule"].([]interface{})[0].(map[string]interface{})["children"].([]interface{})[0].(map[string]interface{})
+	child["metadata"] = map[string]interface{}{"code": testBuildPolicyCode, "extra": "x"}
+
+	d := schema.TestResourceDataRaw(t, resourcePolicy().Schema, raw)
+	_, diags := parsePolicy(d, "")
+	if !diags.HasError() {
+		t.Fatalf("Expected an error for unknown metadata keys")
+	}
+	if got := len(diags[0].AttributePath); got != 5 {
+		t.Errorf("Got an attribute path of length %d, expected 5", got)
+	}
+}
+
+func TestBuildPolicyCode(t *testing.T) {
+	want, err := parseBuildPolicy(testBuildPolicyCode)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	code, err := buildPolicyCode(flattenBuildPolicyConditions(testBuildPolicyCode))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	got, err := parseBuildPolicy(code)
+	if err != nil {
+		t.Fatalf("Built code does not parse: %s\n%s", err, code)
+	}
+
+	// A scalar resource_types comes back as a list.
+	want[2].ResourceTypes = []string{"all"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nexpected:\n%#v\ncode:\n%s", got, want, code)
+	}
+
+	single := []interface{}{map[string]interface{}{
+		"path":           "",
+		"cond_type":      "attribute",
+		"resource_types": []interface{}{"aws_instance"},
+		"attribute":      "monitoring",
+		"operator":       "equals",
+		"value":          `"true"`,
+	}}
+	code, err = buildPolicyCode(single)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := flattenBuildPolicyConditions(code); !reflect.DeepEqual(got[0].(map[string]interface{})["value"], `"true"`) {
+		t.Errorf("The string value became %q:\n%s", got[0].(map[string]interface{})["value"], code)
+	}
+}
+
+func TestBuildPolicyCodeInvalid(t *testing.T) {
+	cond := func(path string) map[string]interface{} {
+		return map[string]interface{}{
+			"path":           path,
+			"cond_type":      "attribute",
+			"resource_types": []interface{}{"a"},
+			"attribute":      "b",
+			"operator":       "exists",
+		}
+	}
+
+	cases := []struct {
+		name string
+		list []interface{}
+		want string
+	}{
+		{"none", nil, "no conditions given"},
+		{"two roots", []interface{}{cond(""), cond("")}, `path "" is used by more than one condition`},
+		{"root and list", []interface{}{cond(""), cond("and[0]")}, `path "and[0]" clashes with another condition`},
+		{"and and or", []interface{}{cond("and[0]"), cond("or[1]")}, `path "or[1]" clashes with another condition`},
+		{"bad step", []interface{}{cond("and.0")}, `path "and.0": expected steps such as`},
+		{"gap", []interface{}{cond("and[0]"), cond("and[2]")}, `there is no condition at "and[1]"`},
+		{"nested gap", []interface{}{cond("or[0].and[1]")}, `there is no condition at "or[0].and[0]"`},
+		{"bad value", []interface{}{func() map[string]interface{} {
+			m := cond("")
+			m["value"] = "public-read"
+			return m
+		}()}, "value is not JSON"},
+		{"bad condition", []interface{}{func() map[string]interface{} {
+			m := cond("and[0]")
+			m["operator"] = ""
+			return m
+		}()}, "and[0].operator: is required"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := buildPolicyCode(tc.list)
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Got %q, expected it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPolicyBuildConditionsRoundTrip(t *testing.T) {
+	r := resourcePolicy()
+	raw := testBuildPolicyConfig()
+	child := raw["rule"].([]interface{})[0].(map[string]interface{})["children"].([]interface{})[0].(map[string]interface{})
+	delete(child, "metadata")
+	child["conditions"] = []interface{}{
+		map[string]interface{}{
+			"path":           "or[0]",
+			"cond_type":      "attribute",
+			"resource_types": []interface{}{"aws_s3_bucket"},
+			"attribute":      "acl",
+			"operator":       "not_equals",
+			"value":          `"public-read"`,
+		},
+		map[string]interface{}{
+			"path":           "or[1]",
+			"cond_type":      "attribute",
+			"resource_types": []interface{}{"aws_s3_bucket"},
+			"attribute":      "versioning.enabled",
+			"operator":       "is_true",
+		},
+	}
+
+	d := testResourceDataWithConfig(t, r.Schema, raw)
+	obj, diags := parsePolicy(d, "")
+	if diags.HasError() {
+		t.Fatalf("Unexpected diagnostics: %v", diags)
+	}
+	if len(obj.Rule.Children) != 1 {
+		t.Fatalf("Children not parsed: %#v", obj.Rule.Children)
+	}
+	conds, err := parseBuildPolicy(obj.Rule.Children[0].Metadata.Code)
+	if err != nil || len(conds) != 2 || conds[1].Attribute != "versioning.enabled" {
+		t.Fatalf("Got conditions %#v / %v from code:\n%s", conds, err, obj.Rule.Children[0].Metadata.Code)
+	}
+
+	// The API hands the definition back re-serialized.
+	code, err := normalizeYaml(obj.Rule.Children[0].Metadata.Code)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	obj.PolicyId = "1234"
+	obj.Rule.Children[0].Metadata.Code = code
+
+	d.SetId(obj.PolicyId)
+	savePolicy(d, obj, nil)
+
+	diff, err := r.Diff(context.Background(), d.State(), terraform.NewResourceConfigRaw(raw), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if diff != nil && len(diff.Attributes) != 0 {
+		for k, v := range diff.Attributes {
+			t.Errorf("Unexpected diff on %s: %q => %q", k, v.Old, v.New)
+		}
+	}
+
+	// Giving both is an error.
+	child["metadata"] = map[string]interface{}{"code": testBuildPolicyCode}
+	d = testResourceDataWithConfig(t, r.Schema, raw)
+	if _, diags := parsePolicy(d, ""); !diags.HasError() {
+		t.Errorf("Expected an error for both metadata and conditions")
+	}
+}
@@ -0,0 +1,239 @@
+package prismacloud
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAlert() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAlertRead,
+
+		Schema: map[string]*schema.Schema{
+			// Input.
+			"alert_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Alert ID",
+			},
+
+			// Output.
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Alert status",
+			},
+			"first_seen": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "First seen",
+			},
+			"last_seen": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Last seen",
+			},
+			"alert_time": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Alert time",
+			},
+			"event_occurred": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Event occurred",
+			},
+			"triggered_by": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Triggered by",
+			},
+			"alert_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Alert count",
+			},
+			"policy": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Policy details",
+				Elem: &schema.Resource{
+					Schema: alertPolicySchema(),
+				},
+			},
+			"resource": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Resource details",
+				Elem: &schema.Resource{
+					Schema: alertResourceSchema(true),
+				},
+			},
+			"risk_score": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Risk score",
+			},
+			"max_risk_score": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Max risk score",
+			},
+			"risk_rating": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Risk rating",
+			},
+			"history": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Status history",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"status": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Alert status",
+						},
+						"reason": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Reason for the status",
+						},
+						"modified_by": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Who set the status",
+						},
+						"modified_on": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "When the status was set",
+						},
+					},
+				},
+			},
+			"investigate_options": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The search to investigate the alert",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"search_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Search ID",
+						},
+						"start_ts": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Start of the search's time range",
+						},
+						"end_ts": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "End of the search's time range",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAlertRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	id := d.Get("alert_id").(string)
+
+	var obj alert.Alert
+	var lastErr error
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		obj, err = alert.Get(client, id)
+		lastErr = err
+		return err
+	}); diags != nil {
+		if lastErr == pc.ObjectNotFoundError {
+			d.SetId("")
+			return nil
+		}
+		return diags
+	}
+
+	// The alert has the policy ID and type, the rest comes from the policy.
+	policies := make(map[string]policy.Policy)
+	if obj.Policy.Id != "" {
+		var p policy.Policy
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			p, err = policy.Get(client, obj.Policy.Id)
+			lastErr = err
+			return err
+		}); diags != nil && lastErr != pc.ObjectNotFoundError {
+			return diags
+		}
+		policies[obj.Policy.Id] = p
+	}
+
+	d.SetId(id)
+	d.Set("status", obj.Status)
+	d.Set("first_seen", obj.FirstSeen)
+	d.Set("last_seen", obj.LastSeen)
+	d.Set("alert_time", obj.AlertTime)
+	d.Set("event_occurred", obj.EventOccurred)
+	d.Set("triggered_by", obj.TriggeredBy)
+	d.Set("alert_count", obj.AlertCount)
+	d.Set("risk_score", obj.Risk.RiskScore.Score)
+	d.Set("max_risk_score", obj.Risk.RiskScore.MaxScore)
+	d.Set("risk_rating", obj.Risk.Rating)
+
+	if err := d.Set("policy", flattenAlertPolicy(obj, policies)); err != nil {
+		log.Printf("[WARN] Error setting 'policy' for %q: %s", d.Id(), err)
+	}
+
+	res := flattenAlertResource(obj)
+	res["data"] = ""
+	if obj.Resource.Data != nil {
+		b, err := json.Marshal(obj.Resource.Data)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		res["data"] = string(b)
+	}
+	if err := d.Set("resource", []interface{}{res}); err != nil {
+		log.Printf("[WARN] Error setting 'resource' for %q: %s", d.Id(), err)
+	}
+
+	history := make([]interface{}, 0, len(obj.History))
+	for _, h := range obj.History {
+		history = append(history, map[string]interface{}{
+			"status":      h.Status,
+			"reason":      h.Reason,
+			"modified_by": h.ModifiedBy,
+			"modified_on": h.ModifiedOn,
+		})
+	}
+	if err := d.Set("history", history); err != nil {
+		log.Printf("[WARN] Error setting 'history' for %q: %s", d.Id(), err)
+	}
+
+	investigate := []interface{}{map[string]interface{}{
+		"search_id": obj.InvestigateOptions.SearchId,
+		"start_ts":  obj.InvestigateOptions.StartTs,
+		"end_ts":    obj.InvestigateOptions.EndTs,
+	}}
+	if err := d.Set("investigate_options", investigate); err != nil {
+		log.Printf("[WARN] Error setting 'investigate_options' for %q: %s", d.Id(), err)
+	}
+
+	return nil
+}
@@ -335,6 +335,7 @@ func dataSourceAlertRule() *schema.Resource {
 							Computed:    true,
 							Description: "Frequency from R rule",
 						},
+						"next_runs": nextRunsSchema("notifications are sent"),
 						"hour_of_day": {
 							Type:        schema.TypeInt,
 							Computed:    true,
@@ -0,0 +1,445 @@
+package prismacloud
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceAlertRuleCoverage() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceAlertRuleCoverageRead,
+
+		Schema: map[string]*schema.Schema{
+			// Input.
+			"include_disabled_rules": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Count disabled alert rules as covering their policies",
+			},
+			"include_disabled_policies": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Report on disabled policies too",
+			},
+
+			// Output.
+			"total": totalSchema("policies"),
+			"policies": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Coverage of each policy",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"policy_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Policy ID",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Policy name",
+						},
+						"policy_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Policy type",
+						},
+						"cloud_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Cloud type",
+						},
+						"severity": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Severity",
+						},
+						"alert_rule_ids": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "IDs of the alert rules covering the policy",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"account_group_ids": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "IDs of the account groups the policy is covered for",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"uncovered_policy_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "IDs of the policies no alert rule covers",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"alert_rules": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Policies matched by each alert rule",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"policy_scan_config_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Policy scan config ID",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Alert rule name",
+						},
+						"enabled": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Enabled",
+						},
+						"account_group_ids": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Target account group IDs",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"policy_count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of policies the alert rule matches",
+						},
+					},
+				},
+			},
+			"empty_alert_rule_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "IDs of the alert rules matching no policies",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"overlaps": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Pairs of alert rules covering the same policies for the same account groups",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"alert_rule_ids": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "IDs of the two alert rules",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"account_group_ids": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Account group IDs both alert rules target",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"policy_ids": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Policy IDs both alert rules cover",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// alertRuleCovers returns if the alert rule raises alerts for the policy.
+//
+// Excluded policies always lose.  Otherwise the policy is covered if it is
+// listed, has one of the alert rule's policy labels, or the alert rule scans
+// all policies and the policy matches its policy filter.
+func alertRuleCovers(r rule.Rule, p policy.Policy) bool {
+	if stringInSlice(p.PolicyId, r.ExcludedPolicies) {
+		return false
+	}
+
+	if stringInSlice(p.PolicyId, r.Policies) {
+		return true
+	}
+
+	for _, label := range r.PolicyLabels {
+		if stringInSlice(label, p.Labels) {
+			return true
+		}
+	}
+
+	return r.ScanAll && alertRulePolicyFilterMatches(r.Target.AlertRulePolicyFilter, p)
+}
+
+// alertRulePolicyFilterMatches returns if the policy matches every param of
+// the filter that is given.  A param matches if any of its values match.
+func alertRulePolicyFilterMatches(f rule.AlertRulePolicyFilter, p policy.Policy) bool {
+	// Policies without a cloud type apply to every cloud.
+	if len(f.CloudType) > 0 && p.CloudType != "" && !stringInSliceFold(p.CloudType, f.CloudType) {
+		return false
+	}
+
+	if len(f.PolicySeverity) > 0 && !stringInSliceFold(p.Severity, f.PolicySeverity) {
+		return false
+	}
+
+	if len(f.PolicyLabel) > 0 {
+		var found bool
+		for _, label := range p.Labels {
+			if stringInSlice(label, f.PolicyLabel) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.PolicyComplianceStandard) > 0 {
+		var found bool
+		for _, cm := range p.ComplianceMetadata {
+			if stringInSlice(cm.StandardName, f.PolicyComplianceStandard) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func stringInSliceFold(str string, list []string) bool {
+	for _, v := range list {
+		if strings.EqualFold(str, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alertRuleCoverage is the result of resolving alert rules against policies.
+type alertRuleCoverage struct {
+	// PolicyRules are the IDs of the alert rules covering each policy.
+	PolicyRules map[string][]string
+	// RulePolicies are the IDs of the policies each alert rule covers.
+	RulePolicies map[string][]string
+	Overlaps     []alertRuleOverlap
+}
+
+// alertRuleOverlap is a pair of alert rules covering the same policies for
+// the same account groups.
+type alertRuleOverlap struct {
+	AlertRuleIds    []string
+	AccountGroupIds []string
+	PolicyIds       []string
+}
+
+// resolveAlertRuleCoverage works out which of the given alert rules cover
+// which of the given policies.  The rules and policies have to be filtered
+// already, and are expected in the order they are reported in.
+func resolveAlertRuleCoverage(rules []rule.Rule, policies []policy.Policy) alertRuleCoverage {
+	ans := alertRuleCoverage{
+		PolicyRules:  make(map[string][]string, len(policies)),
+		RulePolicies: make(map[string][]string, len(rules)),
+	}
+
+	for _, r := range rules {
+		list := make([]string, 0)
+		for _, p := range policies {
+			if alertRuleCovers(r, p) {
+				list = append(list, p.PolicyId)
+				ans.PolicyRules[p.PolicyId] = append(ans.PolicyRules[p.PolicyId], r.PolicyScanConfigId)
+			}
+		}
+		ans.RulePolicies[r.PolicyScanConfigId] = list
+	}
+
+	for i, a := range rules {
+		for _, b := range rules[i+1:] {
+			groups := intersectStrings(a.Target.AccountGroups, b.Target.AccountGroups)
+			if len(groups) == 0 {
+				continue
+			}
+			ids := intersectStrings(ans.RulePolicies[a.PolicyScanConfigId], ans.RulePolicies[b.PolicyScanConfigId])
+			if len(ids) == 0 {
+				continue
+			}
+			ans.Overlaps = append(ans.Overlaps, alertRuleOverlap{
+				AlertRuleIds:    []string{a.PolicyScanConfigId, b.PolicyScanConfigId},
+				AccountGroupIds: groups,
+				PolicyIds:       ids,
+			})
+		}
+	}
+
+	return ans
+}
+
+// intersectStrings returns the values of a that are also in b, in order.
+func intersectStrings(a, b []string) []string {
+	ans := make([]string, 0)
+	for _, v := range a {
+		if stringInSlice(v, b) {
+			ans = append(ans, v)
+		}
+	}
+
+	return ans
+}
+
+func dataSourceAlertRuleCoverageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	includeDisabledRules := d.Get("include_disabled_rules").(bool)
+	includeDisabledPolicies := d.Get("include_disabled_policies").(bool)
+
+	var ruleList []rule.Rule
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		ruleList, err = rule.List(client)
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	var policyList []policy.Policy
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		policyList, err = listPolicies(client, nil)
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	rules := make([]rule.Rule, 0, len(ruleList))
+	for _, r := range ruleList {
+		if !r.Deleted && (r.Enabled || includeDisabledRules) {
+			rules = append(rules, r)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+
+	policies := make([]policy.Policy, 0, len(policyList))
+	for _, p := range policyList {
+		if !p.Deleted && (p.Enabled || includeDisabledPolicies) {
+			policies = append(policies, p)
+		}
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+
+	cov := resolveAlertRuleCoverage(rules, policies)
+
+	groups := make(map[string][]string, len(rules))
+	for _, r := range rules {
+		groups[r.PolicyScanConfigId] = r.Target.AccountGroups
+	}
+
+	policyListing := make([]interface{}, 0, len(policies))
+	uncovered := make([]string, 0)
+	for _, p := range policies {
+		ruleIds := cov.PolicyRules[p.PolicyId]
+		if len(ruleIds) == 0 {
+			uncovered = append(uncovered, p.PolicyId)
+		}
+
+		groupIds := make([]string, 0)
+		for _, id := range ruleIds {
+			for _, g := range groups[id] {
+				if !stringInSlice(g, groupIds) {
+					groupIds = append(groupIds, g)
+				}
+			}
+		}
+		sort.Strings(groupIds)
+
+		policyListing = append(policyListing, map[string]interface{}{
+			"policy_id":         p.PolicyId,
+			"name":              p.Name,
+			"policy_type":       p.PolicyType,
+			"cloud_type":        p.CloudType,
+			"severity":          p.Severity,
+			"alert_rule_ids":    ruleIds,
+			"account_group_ids": groupIds,
+		})
+	}
+
+	ruleListing := make([]interface{}, 0, len(rules))
+	empty := make([]string, 0)
+	for _, r := range rules {
+		n := len(cov.RulePolicies[r.PolicyScanConfigId])
+		if n == 0 {
+			empty = append(empty, r.PolicyScanConfigId)
+		}
+
+		ruleListing = append(ruleListing, map[string]interface{}{
+			"policy_scan_config_id": r.PolicyScanConfigId,
+			"name":                  r.Name,
+			"enabled":               r.Enabled,
+			"account_group_ids":     r.Target.AccountGroups,
+			"policy_count":          n,
+		})
+	}
+
+	overlaps := make([]interface{}, 0, len(cov.Overlaps))
+	for _, o := range cov.Overlaps {
+		overlaps = append(overlaps, map[string]interface{}{
+			"alert_rule_ids":    o.AlertRuleIds,
+			"account_group_ids": o.AccountGroupIds,
+			"policy_ids":        o.PolicyIds,
+		})
+	}
+
+	d.SetId("alert_rule_coverage")
+	d.Set("total", len(policies))
+	if err := d.Set("policies", policyListing); err != nil {
+		log.Printf("[WARN] Error setting 'policies' for %q: %s", d.Id(), err)
+	}
+	if err := d.Set("uncovered_policy_ids", uncovered); err != nil {
+		log.Printf("[WARN] Error setting 'uncovered_policy_ids' for %q: %s", d.Id(), err)
+	}
+	if err := d.Set("alert_rules", ruleListing); err != nil {
+		log.Printf("[WARN] Error setting 'alert_rules' for %q: %s", d.Id(), err)
+	}
+	if err := d.Set("empty_alert_rule_ids", empty); err != nil {
+		log.Printf("[WARN] Error setting 'empty_alert_rule_ids' for %q: %s", d.Id(), err)
+	}
+	if err := d.Set("overlaps", overlaps); err != nil {
+		log.Printf("[WARN] Error setting 'overlaps' for %q: %s", d.Id(), err)
+	}
+
+	return nil
+}
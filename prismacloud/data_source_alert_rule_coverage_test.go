@@ -0,0 +1,129 @@
+package prismacloud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+)
+
+func TestAlertRuleCovers(t *testing.T) {
+	p := policy.Policy{
+		PolicyId:  "p1",
+		CloudType: "aws",
+		Severity:  policy.SeverityHigh,
+		Labels:    []string{"pci", "team-a"},
+		ComplianceMetadata: []policy.ComplianceMetadata{
+			{StandardName: "CIS v1.4.0 (AWS)"},
+		},
+	}
+
+	cases := []struct {
+		name string
+		r    rule.Rule
+		want bool
+	}{
+		{"listed", rule.Rule{Policies: []string{"p1"}}, true},
+		{"not listed", rule.Rule{Policies: []string{"p2"}}, false},
+		{"label", rule.Rule{PolicyLabels: []string{"team-a"}}, true},
+		{"scan all", rule.Rule{ScanAll: true}, true},
+		{"excluded", rule.Rule{ScanAll: true, ExcludedPolicies: []string{"p1"}}, false},
+		{"excluded wins over listed", rule.Rule{Policies: []string{"p1"}, ExcludedPolicies: []string{"p1"}}, false},
+		{"filter matches", rule.Rule{ScanAll: true, Target: rule.Target{AlertRulePolicyFilter: rule.AlertRulePolicyFilter{
+			CloudType:                []string{"AWS", "azure"},
+			PolicySeverity:           []string{"high"},
+			PolicyLabel:              []string{"pci"},
+			PolicyComplianceStandard: []string{"CIS v1.4.0 (AWS)"},
+		}}}, true},
+		{"cloud type", rule.Rule{ScanAll: true, Target: rule.Target{AlertRulePolicyFilter: rule.AlertRulePolicyFilter{
+			CloudType: []string{"gcp"},
+		}}}, false},
+		{"severity", rule.Rule{ScanAll: true, Target: rule.Target{AlertRulePolicyFilter: rule.AlertRulePolicyFilter{
+			PolicySeverity: []string{"low", "medium"},
+		}}}, false},
+		{"compliance standard", rule.Rule{ScanAll: true, Target: rule.Target{AlertRulePolicyFilter: rule.AlertRulePolicyFilter{
+			PolicyComplianceStandard: []string{"NIST 800-53 Rev 5"},
+		}}}, false},
+		{"filter without scan all", rule.Rule{Target: rule.Target{AlertRulePolicyFilter: rule.AlertRulePolicyFilter{
+			CloudType: []string{"aws"},
+		}}}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := alertRuleCovers(tc.r, p); got != tc.want {
+				t.Errorf("Got %t, expected %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveAlertRuleCoverage(t *testing.T) {
+	policies := []policy.Policy{
+		{PolicyId: "p1", CloudType: "aws"},
+		{PolicyId: "p2", CloudType: "azure"},
+		{PolicyId: "p3", CloudType: "gcp"},
+	}
+	rules := []rule.Rule{
+		{PolicyScanConfigId: "all", ScanAll: true, ExcludedPolicies: []string{"p3"}, Target: rule.Target{AccountGroups: []string{"g1", "g2"}}},
+		{PolicyScanConfigId: "aws", ScanAll: true, Target: rule.Target{
+			AccountGroups:         []string{"g2", "g3"},
+			AlertRulePolicyFilter: rule.AlertRulePolicyFilter{CloudType: []string{"aws"}},
+		}},
+		{PolicyScanConfigId: "azure", Policies: []string{"p2"}, Target: rule.Target{AccountGroups: []string{"g3"}}},
+		{PolicyScanConfigId: "none", Policies: []string{"p4"}, Target: rule.Target{AccountGroups: []string{"g1"}}},
+	}
+
+	cov := resolveAlertRuleCoverage(rules, policies)
+
+	wantPolicyRules := map[string][]string{
+		"p1": {"all", "aws"},
+		"p2": {"all", "azure"},
+	}
+	if !reflect.DeepEqual(cov.PolicyRules, wantPolicyRules) {
+		t.Errorf("Policy rules are %v, expected %v", cov.PolicyRules, wantPolicyRules)
+	}
+
+	wantRulePolicies := map[string][]string{
+		"all":   {"p1", "p2"},
+		"aws":   {"p1"},
+		"azure": {"p2"},
+		"none":  {},
+	}
+	if !reflect.DeepEqual(cov.RulePolicies, wantRulePolicies) {
+		t.Errorf("Rule policies are %v, expected %v", cov.RulePolicies, wantRulePolicies)
+	}
+
+	// "all" and "azure" share p2 but no account group.
+	wantOverlaps := []alertRuleOverlap{
+		{AlertRuleIds: []string{"all", "aws"}, AccountGroupIds: []string{"g2"}, PolicyIds: []string{"p1"}},
+	}
+	if !reflect.DeepEqual(cov.Overlaps, wantOverlaps) {
+		t.Errorf("Overlaps are %v, expected %v", cov.Overlaps, wantOverlaps)
+	}
+}
+
+func TestAccDsAlertRuleCoverage(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDsAlertRuleCoverageConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.prismacloud_alert_rule_coverage.test", "total"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alert_rule_coverage.test", "uncovered_policy_ids.#"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alert_rule_coverage.test", "alert_rules.#"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDsAlertRuleCoverageConfig() string {
+	return `
+data "prismacloud_alert_rule_coverage" "test" {}
+`
+}
@@ -0,0 +1,441 @@
+package prismacloud
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account"
+	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account/group"
+	"github.com/paloaltonetworks/prisma-cloud-go/resource-list"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/search"
+	"github.com/paloaltonetworks/prisma-cloud-go/timerange"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceAlertRuleScope() *schema.Resource {
+	ts := resourceAlertRule().Schema["target"].Elem.(*schema.Resource).Schema
+
+	return &schema.Resource{
+		ReadContext: dataSourceAlertRuleScopeRead,
+
+		Schema: map[string]*schema.Schema{
+			// Input.
+			"target": {
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				MaxItems:    1,
+				Description: "Alert rule target to preview",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_groups":           ts["account_groups"],
+						"excluded_accounts":        ts["excluded_accounts"],
+						"regions":                  ts["regions"],
+						"tags":                     ts["tags"],
+						"resource_list":            ts["resource_list"],
+						"alert_rule_policy_filter": ts["alert_rule_policy_filter"],
+					},
+				},
+			},
+			"search_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "config",
+				Description:  "The search type",
+				ValidateFunc: validation.StringInSlice([]string{"config", "asset"}, false),
+			},
+			"where": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "More RQL conditions the resources have to match",
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1000,
+				Description:  "Maximum number of resources read",
+				ValidateFunc: validation.IntBetween(1, 10000),
+			},
+			"sample_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      5,
+				Description:  "Number of sample resources returned per account",
+				ValidateFunc: validation.IntBetween(0, 100),
+			},
+
+			// Output.
+			"query": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The RQL query that was run",
+			},
+			"total": totalSchema("matching resources"),
+			"truncated": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "If the search had more resources than limit",
+			},
+			"accounts": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching resources per cloud account",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Cloud account ID",
+						},
+						"account_name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Cloud account name",
+						},
+						"cloud_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Cloud type",
+						},
+						"resource_count": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of matching resources",
+						},
+						"sample": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Sample of the matching resources",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"resource_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Resource ID",
+									},
+									"name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Resource name",
+									},
+									"resource_type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Resource type",
+									},
+									"region": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Region",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// alertRuleScopeConfigItem is a config search result with its resource JSON.
+type alertRuleScopeConfigItem struct {
+	search.ConfigItem
+	Id   string      `json:"id"`
+	Data interface{} `json:"data"`
+}
+
+// alertRuleScopeResource is a resource found by either search type.
+type alertRuleScopeResource struct {
+	AccountId    string
+	AccountName  string
+	CloudType    string
+	Id           string
+	Name         string
+	ResourceType string
+	RegionId     string
+	RegionName   string
+	Tags         map[string]string
+}
+
+// alertRuleScopeQuery is the RQL query for the resources in the account
+// groups with the given names, leaving out the excluded accounts (by name)
+// and, if given, only in the regions.  Tags are not expressible in RQL for
+// every cloud, so they are applied to the results instead.
+func alertRuleScopeQuery(searchType string, groupNames, excludedNames, regions []string, where string) string {
+	quote := func(list []string) string {
+		quoted := make([]string, 0, len(list))
+		for _, v := range list {
+			quoted = append(quoted, "'"+strings.ReplaceAll(v, "'", "\\'")+"'")
+		}
+		return strings.Join(quoted, ", ")
+	}
+
+	var b strings.Builder
+	if searchType == "asset" {
+		b.WriteString("asset where ")
+	} else {
+		b.WriteString("config from cloud.resource where ")
+	}
+	fmt.Fprintf(&b, "cloud.accountgroup IN ( %s )", quote(groupNames))
+	if len(excludedNames) > 0 {
+		fmt.Fprintf(&b, " AND cloud.account NOT IN ( %s )", quote(excludedNames))
+	}
+	if len(regions) > 0 {
+		fmt.Fprintf(&b, " AND cloud.region IN ( %s )", quote(regions))
+	}
+	if where = strings.TrimSpace(where); where != "" {
+		fmt.Fprintf(&b, " AND ( %s )", where)
+	}
+
+	return b.String()
+}
+
+// alertRuleScopeMatches returns if the resource has one of the target's tags,
+// if given.  A tag matches if the resource has any of the tag's values.
+func alertRuleScopeMatches(tgt rule.Target, r alertRuleScopeResource) bool {
+	if len(tgt.Tags) == 0 {
+		return true
+	}
+	for _, tag := range tgt.Tags {
+		if value, ok := r.Tags[tag.Key]; ok && (len(tag.Values) == 0 || stringInSlice(value, tag.Values)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// searchAlertRuleScope runs the query, returning up to limit resources and
+// if there were more.
+func searchAlertRuleScope(client *pc.Client, searchType, query string, limit int) ([]alertRuleScopeResource, bool, error) {
+	if searchType == "asset" {
+		res, err := queryRql(client, searchType, "", query, timerange.TimeRange{}, limit, false, nil)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items := res.Items.([]search.AssetValue)
+		ans := make([]alertRuleScopeResource, 0, len(items))
+		for _, v := range items {
+			ans = append(ans, alertRuleScopeResource{
+				AccountId:    v.CloudAccountId,
+				AccountName:  v.CloudAccountName,
+				CloudType:    v.CloudType,
+				Id:           v.ExternalAssetId,
+				Name:         v.AssetName,
+				ResourceType: v.AssetType,
+				RegionId:     v.CloudRegion,
+				RegionName:   v.CloudRegion,
+			})
+		}
+		return ans, res.Truncated, nil
+	}
+
+	resp, _, more, err := runRqlConfigQuery[alertRuleScopeConfigItem](client, search.ConfigRequest{
+		Query:            query,
+		WithResourceJson: true,
+		TimeRange: timerange.TimeRange{
+			Type:  timerange.TypeToNow,
+			Value: timerange.Epoch,
+		},
+	}, limit, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ans := make([]alertRuleScopeResource, 0, len(resp.Data.Items))
+	for _, v := range resp.Data.Items {
+		r := alertRuleScopeResource{
+			AccountId:    v.AccountId,
+			AccountName:  v.AccountName,
+			CloudType:    v.CloudType,
+			Id:           v.Id,
+			Name:         v.Name,
+			ResourceType: v.ResourceType,
+			RegionId:     v.RegionId,
+			RegionName:   v.RegionName,
+		}
+		if data, ok := v.Data.(map[string]interface{}); ok {
+			// GCP resources have labels instead of tags.
+			r.Tags = resourceTags(data["tags"])
+			for key, value := range resourceTags(data["labels"]) {
+				r.Tags[key] = value
+			}
+		}
+		ans = append(ans, r)
+	}
+
+	return ans, more, nil
+}
+
+func dataSourceAlertRuleScopeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	searchType := d.Get("search_type").(string)
+	sampleSize := d.Get("sample_size").(int)
+
+	var diags diag.Diagnostics
+	var lastErr error
+
+	tgt := d.Get("target").([]interface{})[0].(map[string]interface{})
+	target := rule.Target{
+		AccountGroups:    SetToStringSlice(tgt["account_groups"].(*schema.Set)),
+		ExcludedAccounts: SetToStringSlice(tgt["excluded_accounts"].(*schema.Set)),
+		Regions:          SetToStringSlice(tgt["regions"].(*schema.Set)),
+	}
+	for _, v := range tgt["tags"].([]interface{}) {
+		tag := v.(map[string]interface{})
+		target.Tags = append(target.Tags, rule.Tag{
+			Key:    tag["key"].(string),
+			Values: ListToStringSlice(tag["values"].([]interface{})),
+		})
+	}
+
+	if len(target.AccountGroups) == 0 {
+		return diag.Errorf("target.0.account_groups: at least one account group is needed")
+	}
+
+	// Compute access groups limit which workloads Compute raises alerts for,
+	// which the cloud resource searches know nothing about.
+	if rl := tgt["resource_list"].([]interface{}); len(rl) > 0 && rl[0] != nil {
+		for _, id := range ListToStringSlice(rl[0].(map[string]interface{})["compute_access_group_ids"].([]interface{})) {
+			name := id
+			var obj resource_list.ResourceList
+			if rdiags := RetryWithBackoff(client, func() error {
+				var err error
+				obj, err = resource_list.Get(client, id)
+				return err
+			}); rdiags == nil {
+				name = fmt.Sprintf("%s (%s)", obj.Name, id)
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Resource list is not previewed",
+				Detail:   fmt.Sprintf("Resource list %s only scopes Compute workload alerts, so it does not narrow the cloud resources shown.", name),
+			})
+		}
+	}
+
+	if searchType == "asset" && len(target.Tags) > 0 {
+		return append(diags, diag.Errorf("target.0.tags: asset searches do not return resource tags, use search_type \"config\"")...)
+	}
+
+	groupNames := make([]string, 0, len(target.AccountGroups))
+	for _, id := range target.AccountGroups {
+		var obj group.Group
+		if gdiags := RetryWithBackoff(client, func() error {
+			var err error
+			obj, err = group.Get(client, id)
+			lastErr = err
+			return err
+		}); gdiags != nil {
+			if lastErr == pc.AccountGroupNotFoundError || lastErr == pc.ObjectNotFoundError {
+				return append(diags, diag.Errorf("target.0.account_groups: account group %q does not exist", id)...)
+			}
+			return append(diags, gdiags...)
+		}
+		groupNames = append(groupNames, obj.Name)
+	}
+	sort.Strings(groupNames)
+
+	// RQL matches accounts by name.  Excluded accounts that don't exist
+	// have no resources to leave out.
+	var excludedNames []string
+	if len(target.ExcludedAccounts) > 0 {
+		var names []account.NameTypeId
+		if adiags := RetryWithBackoff(client, func() error {
+			var err error
+			names, err = account.Names(client)
+			return err
+		}); adiags != nil {
+			return append(diags, adiags...)
+		}
+		for _, v := range names {
+			if stringInSlice(v.AccountId, target.ExcludedAccounts) {
+				excludedNames = append(excludedNames, v.Name)
+			}
+		}
+		sort.Strings(excludedNames)
+	}
+	sort.Strings(target.Regions)
+
+	query := alertRuleScopeQuery(searchType, groupNames, excludedNames, target.Regions, d.Get("where").(string))
+	if _, err := parseRql(query); err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "where may not be valid RQL",
+			Detail:   err.Error(),
+		})
+	}
+
+	list, more, err := searchAlertRuleScope(client, searchType, query, d.Get("limit").(int))
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	var total int
+	accounts := make(map[string]map[string]interface{})
+	for _, r := range list {
+		if !alertRuleScopeMatches(target, r) {
+			continue
+		}
+		total++
+
+		acct, ok := accounts[r.AccountId]
+		if !ok {
+			acct = map[string]interface{}{
+				"account_id":     r.AccountId,
+				"account_name":   r.AccountName,
+				"cloud_type":     r.CloudType,
+				"resource_count": 0,
+				"sample":         make([]interface{}, 0, sampleSize),
+			}
+			accounts[r.AccountId] = acct
+		}
+		acct["resource_count"] = acct["resource_count"].(int) + 1
+
+		if sample := acct["sample"].([]interface{}); len(sample) < sampleSize {
+			region := r.RegionName
+			if region == "" {
+				region = r.RegionId
+			}
+			acct["sample"] = append(sample, map[string]interface{}{
+				"resource_id":   r.Id,
+				"name":          r.Name,
+				"resource_type": r.ResourceType,
+				"region":        region,
+			})
+		}
+	}
+
+	listing := make([]interface{}, 0, len(accounts))
+	for _, acct := range accounts {
+		listing = append(listing, acct)
+	}
+	sort.Slice(listing, func(i, j int) bool {
+		a, b := listing[i].(map[string]interface{}), listing[j].(map[string]interface{})
+		if a["account_name"] != b["account_name"] {
+			return a["account_name"].(string) < b["account_name"].(string)
+		}
+		return a["account_id"].(string) < b["account_id"].(string)
+	})
+
+	d.SetId(buildRqlSearchId(searchType, query, ""))
+	d.Set("query", query)
+	d.Set("total", total)
+	d.Set("truncated", more)
+	if err := d.Set("accounts", listing); err != nil {
+		log.Printf("[WARN] Error setting 'accounts' for %q: %s", d.Id(), err)
+	}
+
+	return diags
+}
@@ -0,0 +1,98 @@
+package prismacloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+)
+
+func TestAlertRuleScopeQuery(t *testing.T) {
+	cases := []struct {
+		searchType string
+		groups     []string
+		excluded   []string
+		regions    []string
+		where      string
+		want       string
+	}{
+		{"config", []string{"Prod"}, nil, nil, "", "config from cloud.resource where cloud.accountgroup IN ( 'Prod' )"},
+		{"config", []string{"Dev", "O'Brien"}, nil, nil, " api.name = 'aws-s3api-get-bucket-acl' ", "config from cloud.resource where cloud.accountgroup IN ( 'Dev', 'O\\'Brien' ) AND ( api.name = 'aws-s3api-get-bucket-acl' )"},
+		{"config", []string{"Prod"}, []string{"sandbox", "test"}, []string{"AWS Ohio", "AWS Virginia"}, "api.name = 'aws-ec2-describe-instances'", "config from cloud.resource where cloud.accountgroup IN ( 'Prod' ) AND cloud.account NOT IN ( 'sandbox', 'test' ) AND cloud.region IN ( 'AWS Ohio', 'AWS Virginia' ) AND ( api.name = 'aws-ec2-describe-instances' )"},
+		{"asset", []string{"Prod"}, nil, nil, "", "asset where cloud.accountgroup IN ( 'Prod' )"},
+		{"asset", []string{"Prod"}, []string{"sandbox"}, []string{"AWS Ohio"}, "", "asset where cloud.accountgroup IN ( 'Prod' ) AND cloud.account NOT IN ( 'sandbox' ) AND cloud.region IN ( 'AWS Ohio' )"},
+	}
+
+	for _, tc := range cases {
+		got := alertRuleScopeQuery(tc.searchType, tc.groups, tc.excluded, tc.regions, tc.where)
+		if got != tc.want {
+			t.Errorf("Got %q, expected %q", got, tc.want)
+		}
+		if _, err := parseRql(got); err != nil {
+			t.Errorf("%q does not parse: %s", got, err)
+		}
+	}
+}
+
+func TestAlertRuleScopeMatches(t *testing.T) {
+	r := alertRuleScopeResource{
+		AccountId: "123456789012",
+		Tags:      map[string]string{"env": "prod", "team": "a"},
+	}
+
+	cases := []struct {
+		name string
+		tgt  rule.Target
+		want bool
+	}{
+		{"everything", rule.Target{}, true},
+		{"tag", rule.Target{Tags: []rule.Tag{{Key: "env", Values: []string{"dev", "prod"}}}}, true},
+		{"tag value", rule.Target{Tags: []rule.Tag{{Key: "env", Values: []string{"dev"}}}}, false},
+		{"any tag", rule.Target{Tags: []rule.Tag{{Key: "env", Values: []string{"dev"}}, {Key: "team", Values: []string{"a"}}}}, true},
+		{"tag key", rule.Target{Tags: []rule.Tag{{Key: "team"}}}, true},
+		{"missing tag", rule.Target{Tags: []rule.Tag{{Key: "owner"}}}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := alertRuleScopeMatches(tc.tgt, r); got != tc.want {
+				t.Errorf("Got %t, expected %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAccDsAlertRuleScope(t *testing.T) {
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDsAlertRuleScopeConfig(name),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.prismacloud_alert_rule_scope.test", "query", fmt.Sprintf("config from cloud.resource where cloud.accountgroup IN ( '%s' )", name)),
+					resource.TestCheckResourceAttr("data.prismacloud_alert_rule_scope.test", "total", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDsAlertRuleScopeConfig(name string) string {
+	return fmt.Sprintf(`
+resource "prismacloud_account_group" "x" {
+    name = %q
+    description = "for alert rule scope acctest"
+}
+
+data "prismacloud_alert_rule_scope" "test" {
+    target {
+        account_groups = [prismacloud_account_group.x.group_id]
+    }
+}
+`, name)
+}
@@ -0,0 +1,41 @@
+package prismacloud
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDsAlert(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDsAlertConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.prismacloud_alert.test", "status"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alert.test", "policy.0.policy_id"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alert.test", "resource.0.resource_id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDsAlertConfig() string {
+	return `
+data "prismacloud_alerts" "x" {
+    limit = 1
+    time_range {
+        to_now {
+            unit = "epoch"
+        }
+    }
+}
+
+data "prismacloud_alert" "test" {
+    alert_id = data.prismacloud_alerts.x.listing.0.alert_id
+}
+`
+}
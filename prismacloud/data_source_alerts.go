@@ -4,15 +4,152 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"golang.org/x/net/context"
 	"log"
+	"sort"
 
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// alertsPageSize is the most alerts the v2 API returns at once.
+const alertsPageSize = 10000
+
+// Valid values for group_by.
+const (
+	alertGroupPolicy   = "policy"
+	alertGroupSeverity = "severity"
+	alertGroupAccount  = "account"
+	alertGroupRegion   = "region"
+)
+
+func alertPolicySchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"policy_id": {
+			Type:        schema.TypeString,
+			Description: "Policy ID",
+			Computed:    true,
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Description: "Policy name",
+			Computed:    true,
+		},
+		"policy_type": {
+			Type:        schema.TypeString,
+			Description: "Policy type",
+			Computed:    true,
+		},
+		"severity": {
+			Type:        schema.TypeString,
+			Description: "Policy severity",
+			Computed:    true,
+		},
+		"system_default": {
+			Type:        schema.TypeBool,
+			Description: "If the policy is a system default policy",
+			Computed:    true,
+		},
+		"remediable": {
+			Type:        schema.TypeBool,
+			Description: "If the policy is remediable",
+			Computed:    true,
+		},
+	}
+}
+
+// alertResourceSchema is the schema of an alert's resource, optionally with
+// the resource's data.
+func alertResourceSchema(withData bool) map[string]*schema.Schema {
+	ans := map[string]*schema.Schema{
+		"resource_id": {
+			Type:        schema.TypeString,
+			Description: "Resource ID",
+			Computed:    true,
+		},
+		"rrn": {
+			Type:        schema.TypeString,
+			Description: "Resource RRN",
+			Computed:    true,
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Description: "Resource name",
+			Computed:    true,
+		},
+		"account": {
+			Type:        schema.TypeString,
+			Description: "Cloud account name",
+			Computed:    true,
+		},
+		"account_id": {
+			Type:        schema.TypeString,
+			Description: "Cloud account ID",
+			Computed:    true,
+		},
+		"cloud_account_groups": {
+			Type:        schema.TypeList,
+			Description: "Cloud account groups",
+			Computed:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"region": {
+			Type:        schema.TypeString,
+			Description: "Region name",
+			Computed:    true,
+		},
+		"region_id": {
+			Type:        schema.TypeString,
+			Description: "Region ID",
+			Computed:    true,
+		},
+		"resource_type": {
+			Type:        schema.TypeString,
+			Description: "Resource type",
+			Computed:    true,
+		},
+		"resource_api_name": {
+			Type:        schema.TypeString,
+			Description: "Resource API name",
+			Computed:    true,
+		},
+		"cloud_type": {
+			Type:        schema.TypeString,
+			Description: "Cloud type",
+			Computed:    true,
+		},
+		"url": {
+			Type:        schema.TypeString,
+			Description: "Resource URL",
+			Computed:    true,
+		},
+		"tags": {
+			Type:        schema.TypeMap,
+			Description: "Resource tags",
+			Computed:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+	}
+
+	if withData {
+		ans["data"] = &schema.Schema{
+			Type:        schema.TypeString,
+			Description: "Resource data, as JSON",
+			Computed:    true,
+		}
+	}
+
+	return ans
+}
+
 func dataSourceAlerts() *schema.Resource {
-	return &schema.Resource{
+	ans := &schema.Resource{
 		ReadContext: dataSourceAlertsRead,
 
 		Schema: map[string]*schema.Schema{
@@ -21,9 +158,28 @@ func dataSourceAlerts() *schema.Resource {
 			"limit": {
 				Type:        schema.TypeInt,
 				Optional:    true,
-				Description: "Max number of alerts to return.  This uses the v2 version of the API, where the default and max is 10,000.",
+				Description: "Max number of alerts to return.  Pages of up to 10,000 alerts are read until limit alerts are read.",
 				Default:     10000,
 			},
+			"detailed": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Include the policy, resource and risk details of each alert",
+			},
+			"group_by": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Return the number of alerts per group instead of the alerts",
+				ValidateFunc: validation.StringInSlice(
+					[]string{
+						alertGroupPolicy,
+						alertGroupSeverity,
+						alertGroupAccount,
+						alertGroupRegion,
+					},
+					false,
+				),
+			},
 			"filters": {
 				Type:        schema.TypeList,
 				Optional:    true,
@@ -111,11 +267,65 @@ func dataSourceAlerts() *schema.Resource {
 							Description: "Alert count",
 							Computed:    true,
 						},
+						"policy": {
+							Type:        schema.TypeList,
+							Description: "Policy details, if detailed",
+							Computed:    true,
+							Elem: &schema.Resource{
+								Schema: alertPolicySchema(),
+							},
+						},
+						"resource": {
+							Type:        schema.TypeList,
+							Description: "Resource details, if detailed",
+							Computed:    true,
+							Elem: &schema.Resource{
+								Schema: alertResourceSchema(false),
+							},
+						},
+						"risk_score": {
+							Type:        schema.TypeInt,
+							Description: "Risk score, if detailed",
+							Computed:    true,
+						},
+						"risk_rating": {
+							Type:        schema.TypeString,
+							Description: "Risk rating, if detailed",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"groups": {
+				Type:        schema.TypeList,
+				Description: "Number of alerts per group, if group_by is set",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Description: "Policy ID, severity, account ID or region ID",
+							Computed:    true,
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Description: "Policy, account or region name",
+							Computed:    true,
+						},
+						"alert_count": {
+							Type:        schema.TypeInt,
+							Description: "Number of alerts",
+							Computed:    true,
+						},
 					},
 				},
 			},
 		},
 	}
+
+	addOutputFileSchema(ans.Schema)
+
+	return ans
 }
 
 func parseAlertsRequest(d *schema.ResourceData) *alert.Request {
@@ -139,21 +349,27 @@ func parseAlertsRequest(d *schema.ResourceData) *alert.Request {
 	return &ans
 }
 
-func dataSourceAlertsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*pc.Client)
+// listAlerts follows the page tokens of an alert listing until limit alerts
+// are read, passing each page to fn.  The response has the total and the
+// token of the next page, if any.
+func listAlerts(client *pc.Client, req alert.Request, limit int, fn func([]alert.Alert) error) (alert.Response, error) {
+	var ans alert.Response
+	var read int
 
-	req := parseAlertsRequest(d)
-	ans, err := alert.List(client, *req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	for {
+		req.Limit = min(limit-read, alertsPageSize)
 
-	d.SetId(client.Url)
-	d.Set("page_token", ans.PageToken)
-	d.Set("total", ans.Total)
+		var page alert.Response
+		var lastErr error
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			page, err = alert.List(client, req)
+			lastErr = err
+			return err
+		}); diags != nil {
+			return ans, lastErr
+		}
 
-	data := make([]interface{}, 0, len(ans.Data))
-	for num, info := range ans.Data {
 		// TODO(shinmog) - Remove this workaround when Prisma Cloud fixes their bug.
 		//
 		// WORKAROUND: Prisma Cloud does not honor the limit for to_now queries, so
@@ -163,23 +379,247 @@ func dataSourceAlertsRead(ctx context.Context, d *schema.ResourceData, meta inte
 		//
 		// The `total` value is being intentionally left as-is so later on it will be
 		// easier to see when they've fixed this on their end.
-		if num >= req.Limit {
-			break
+		if len(page.Data) > req.Limit {
+			page.Data = page.Data[:req.Limit]
+		}
+		if err := fn(page.Data); err != nil {
+			return ans, err
+		}
+
+		read += len(page.Data)
+		ans.Total = page.Total
+		ans.PageToken = page.PageToken
+		if page.PageToken == "" || len(page.Data) == 0 || read >= limit {
+			return ans, nil
+		}
+		req.PageToken = page.PageToken
+	}
+}
+
+// alertPolicies returns the policies by ID, for the policy details that
+// alerts leave out.
+func alertPolicies(client *pc.Client) (map[string]policy.Policy, error) {
+	var list []policy.Policy
+	var lastErr error
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		list, err = policy.List(client, nil)
+		lastErr = err
+		return err
+	}); diags != nil {
+		return nil, lastErr
+	}
+
+	ans := make(map[string]policy.Policy, len(list))
+	for _, p := range list {
+		ans[p.PolicyId] = p
+	}
+
+	return ans, nil
+}
+
+func flattenAlertPolicy(a alert.Alert, policies map[string]policy.Policy) []interface{} {
+	p := policies[a.Policy.Id]
+
+	return []interface{}{map[string]interface{}{
+		"policy_id":      a.Policy.Id,
+		"name":           p.Name,
+		"policy_type":    a.Policy.Type,
+		"severity":       p.Severity,
+		"system_default": a.Policy.SystemDefault,
+		"remediable":     a.Policy.Remediable,
+	}}
+}
+
+func flattenAlertResource(a alert.Alert) map[string]interface{} {
+	r := a.Resource
+
+	tags := make(map[string]interface{})
+	for k, v := range alertResourceTags(a) {
+		tags[k] = v
+	}
+
+	return map[string]interface{}{
+		"resource_id":          r.Id,
+		"rrn":                  r.Rrn,
+		"name":                 r.Name,
+		"account":              r.Account,
+		"account_id":           r.AccountId,
+		"cloud_account_groups": r.CloudAccountGroups,
+		"region":               r.Region,
+		"region_id":            r.RegionId,
+		"resource_type":        r.ResourceType,
+		"resource_api_name":    r.ResourceApiName,
+		"cloud_type":           r.CloudType,
+		"url":                  r.Url,
+		"tags":                 tags,
+	}
+}
+
+func flattenAlertListing(a alert.Alert, policies map[string]policy.Policy) map[string]interface{} {
+	ans := map[string]interface{}{
+		"alert_id":       a.Id,
+		"status":         a.Status,
+		"first_seen":     a.FirstSeen,
+		"last_seen":      a.LastSeen,
+		"alert_time":     a.AlertTime,
+		"event_occurred": a.EventOccurred,
+		"triggered_by":   a.TriggeredBy,
+		"alert_count":    a.AlertCount,
+	}
+
+	if policies != nil {
+		ans["policy"] = flattenAlertPolicy(a, policies)
+		ans["resource"] = []interface{}{flattenAlertResource(a)}
+		ans["risk_score"] = a.Risk.RiskScore.Score
+		ans["risk_rating"] = a.Risk.Rating
+	}
+
+	return ans
+}
+
+// alertGroup is the number of alerts in a group.
+type alertGroup struct {
+	Key   string
+	Name  string
+	Count int
+}
+
+// alertGroups counts alerts by policy, severity, account or region.
+type alertGroups struct {
+	by       string
+	policies map[string]policy.Policy
+	groups   map[string]*alertGroup
+}
+
+func (o *alertGroups) Add(a alert.Alert) {
+	var key, name string
+	switch o.by {
+	case alertGroupPolicy:
+		key, name = a.Policy.Id, o.policies[a.Policy.Id].Name
+	case alertGroupSeverity:
+		key = o.policies[a.Policy.Id].Severity
+		name = key
+	case alertGroupAccount:
+		key, name = a.Resource.AccountId, a.Resource.Account
+	case alertGroupRegion:
+		key, name = a.Resource.RegionId, a.Resource.Region
+	}
+
+	g, ok := o.groups[key]
+	if !ok {
+		g = &alertGroup{Key: key, Name: name}
+		o.groups[key] = g
+	}
+	g.Count++
+}
+
+// Flatten returns the groups, largest first.
+func (o *alertGroups) Flatten() []interface{} {
+	list := make([]*alertGroup, 0, len(o.groups))
+	for _, g := range o.groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Key < list[j].Key
+	})
+
+	ans := make([]interface{}, 0, len(list))
+	for _, g := range list {
+		ans = append(ans, map[string]interface{}{
+			"key":         g.Key,
+			"name":        g.Name,
+			"alert_count": g.Count,
+		})
+	}
+
+	return ans
+}
+
+func dataSourceAlertsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	detailed := d.Get("detailed").(bool)
+	groupBy := d.Get("group_by").(string)
+
+	req := parseAlertsRequest(d)
+	req.Detailed = detailed || groupBy != ""
+
+	// Policy names and severities are looked up separately.
+	var policies map[string]policy.Policy
+	if detailed || groupBy == alertGroupPolicy || groupBy == alertGroupSeverity {
+		var err error
+		if policies, err = alertPolicies(client); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	var out *resultFile
+	if path := d.Get("output_file").(string); path != "" {
+		var err error
+		if out, err = createResultFile(path, d.Get("output_format").(string), alert.Alert{}); err != nil {
+			return diag.FromErr(err)
 		}
-		item := map[string]interface{}{
-			"alert_id":       info.Id,
-			"status":         info.Status,
-			"first_seen":     info.FirstSeen,
-			"last_seen":      info.LastSeen,
-			"alert_time":     info.AlertTime,
-			"event_occurred": info.EventOccurred,
-			"triggered_by":   info.TriggeredBy,
-			"alert_count":    info.AlertCount,
+	}
+
+	var groups *alertGroups
+	if groupBy != "" {
+		groups = &alertGroups{
+			by:       groupBy,
+			policies: policies,
+			groups:   make(map[string]*alertGroup),
+		}
+	}
+
+	// Alerts only go to state when they aren't written to a file or grouped.
+	listing := make([]interface{}, 0)
+	ans, err := listAlerts(client, *req, req.Limit, func(list []alert.Alert) error {
+		for _, a := range list {
+			if groups != nil {
+				groups.Add(a)
+			}
+			if out != nil {
+				if err := out.Write(a); err != nil {
+					return err
+				}
+			} else if groups == nil {
+				listing = append(listing, flattenAlertListing(a, policies))
+			}
 		}
-		data = append(data, item)
+		return nil
+	})
+	if err != nil {
+		if out != nil {
+			out.Abort()
+		}
+		return diag.FromErr(err)
+	}
+
+	if out != nil {
+		if err = out.Close(); err != nil {
+			return diag.FromErr(err)
+		}
+		out.Save(d)
+	} else {
+		clearOutputFile(d)
+	}
+
+	d.SetId(client.Url)
+	d.Set("page_token", ans.PageToken)
+	d.Set("total", ans.Total)
+
+	if err := d.Set("listing", listing); err != nil {
+		log.Printf("[WARN] Error setting 'listing' for %q: %s", d.Id(), err)
+	}
+
+	var list []interface{}
+	if groups != nil {
+		list = groups.Flatten()
 	}
-	if err := d.Set("listing", data); err != nil {
-		log.Printf("[WARN] Error setting 'data' for %q: %s", d.Id(), err)
+	if err := d.Set("groups", list); err != nil {
+		log.Printf("[WARN] Error setting 'groups' for %q: %s", d.Id(), err)
 	}
 
 	return nil
@@ -8,8 +8,8 @@ import (
 
 func TestAccDsAbsoluteAlerts(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDsAlertsConfig("absolute"),
@@ -23,8 +23,8 @@ func TestAccDsAbsoluteAlerts(t *testing.T) {
 
 func TestAccDsRelativeAlerts(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDsAlertsConfig("relative"),
@@ -38,8 +38,8 @@ func TestAccDsRelativeAlerts(t *testing.T) {
 
 func TestAccDsToNowAlerts(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDsAlertsConfig("to_now"),
@@ -51,6 +51,40 @@ func TestAccDsToNowAlerts(t *testing.T) {
 	})
 }
 
+func TestAccDsDetailedAlerts(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDsAlertsConfig("detailed"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.prismacloud_alerts.test", "total"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alerts.test", "listing.0.policy.0.policy_id"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alerts.test", "listing.0.resource.0.account_id"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDsGroupedAlerts(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDsAlertsConfig("group_by"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.prismacloud_alerts.test", "listing.#", "0"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alerts.test", "groups.0.key"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_alerts.test", "groups.0.alert_count"),
+				),
+			},
+		},
+	})
+}
+
 func testAccDsAlertsConfig(ct string) string {
 	switch ct {
 	case "absolute":
@@ -87,6 +121,30 @@ data "prismacloud_alerts" "test" {
         }
     }
 }
+`
+	case "detailed":
+		return `
+data "prismacloud_alerts" "test" {
+    limit = 2
+    detailed = true
+    time_range {
+        to_now {
+            unit = "epoch"
+        }
+    }
+}
+`
+	case "group_by":
+		return `
+data "prismacloud_alerts" "test" {
+    limit = 20000
+    group_by = "severity"
+    time_range {
+        to_now {
+            unit = "epoch"
+        }
+    }
+}
 `
 	}
 
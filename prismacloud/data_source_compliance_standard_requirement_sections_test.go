@@ -14,8 +14,8 @@ func TestAccDsComplianceStandardRequirementSections(t *testing.T) {
 	csrs := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDsComplianceStandardRequirementSectionsConfig(cs, csr, csrs),
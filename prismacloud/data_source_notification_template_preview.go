@@ -0,0 +1,254 @@
+package prismacloud
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+	notification_template "github.com/paloaltonetworks/prisma-cloud-go/notification-template"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceNotificationTemplatePreview() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceNotificationTemplatePreviewRead,
+
+		Schema: map[string]*schema.Schema{
+			// Input.
+			"template_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Render this notification template",
+				ExactlyOneOf: []string{"template_id", "template_config"},
+			},
+			"integration_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{
+						notification_template.Email,
+						notification_template.ServiceNow,
+						notification_template.Jira,
+					},
+					false,
+				),
+				Description:  "Integration type of template_config",
+				RequiredWith: []string{"integration_type", "template_config"},
+			},
+			"template_config": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				Description:  "Template config, as in prismacloud_notification_template",
+				Elem:         templateConfigSchema(),
+				RequiredWith: []string{"integration_type", "template_config"},
+			},
+			"alert_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Render the notification of this alert",
+				ExactlyOneOf: []string{"alert_id", "alert_json"},
+			},
+			"alert_json": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Sample alert, as JSON",
+				ValidateFunc: validation.StringIsJSON,
+			},
+
+			// Output.
+			"states": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The rendered notification of each alert state",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"state": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Alert state",
+						},
+						"subject": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Rendered subject",
+						},
+						"body": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Rendered body",
+						},
+						"fields": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "All rendered fields",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"field_name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Field name",
+									},
+									"display_name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Display name",
+									},
+									"value": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Rendered value",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"unknown_aliases": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Aliases in the template that are not alert fields",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNotificationTemplatePreviewRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	var diags diag.Diagnostics
+
+	integrationType := d.Get("integration_type").(string)
+	conf := parseTemplateConfig(d.Get("template_config").([]interface{}))
+	if id := d.Get("template_id").(string); id != "" {
+		var obj notification_template.NotificationTemplate
+		if rdiags := RetryWithBackoff(client, func() error {
+			var err error
+			obj, err = notification_template.Get(client, id)
+			return err
+		}); rdiags != nil {
+			return rdiags
+		}
+
+		// A missing template comes back empty.
+		if obj.Id == "" {
+			return diag.Errorf("Notification template %q not found", id)
+		}
+		integrationType = obj.IntegrationType
+		conf = obj.TemplateConfig
+	}
+
+	var obj alert.Alert
+	var p policy.Policy
+	if id := d.Get("alert_id").(string); id != "" {
+		var lastErr error
+		if rdiags := RetryWithBackoff(client, func() error {
+			var err error
+			obj, err = alert.Get(client, id)
+			lastErr = err
+			return err
+		}); rdiags != nil {
+			if lastErr == pc.ObjectNotFoundError {
+				return diag.Errorf("Alert %q not found", id)
+			}
+			return rdiags
+		}
+	} else {
+		// The sample may have the policy details, which aren't part of an
+		// alert.Alert.
+		s := []byte(d.Get("alert_json").(string))
+		var sample struct {
+			Policy policy.Policy `json:"policy"`
+		}
+		if err := json.Unmarshal(s, &obj); err != nil {
+			return diag.Errorf("alert_json must be an alert: %s", err)
+		}
+		if err := json.Unmarshal(s, &sample); err != nil {
+			return diag.Errorf("alert_json must be an alert: %s", err)
+		}
+		p = sample.Policy
+	}
+
+	// The alert has the policy ID and type, the rest comes from the policy.
+	if p.Name == "" && obj.Policy.Id != "" {
+		var lastErr error
+		if rdiags := RetryWithBackoff(client, func() error {
+			var err error
+			p, err = policy.Get(client, obj.Policy.Id)
+			lastErr = err
+			return err
+		}); rdiags != nil {
+			if lastErr != pc.ObjectNotFoundError {
+				return rdiags
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Alert policy not found",
+				Detail:   fmt.Sprintf("Policy %q of the alert does not exist, so the policy fields are left empty.", obj.Policy.Id),
+			})
+		}
+	}
+
+	previews := renderNotificationTemplate(integrationType, conf, obj, p)
+	unknown := notificationTemplateUnknownAliases(conf)
+
+	states := make([]interface{}, 0, len(previews))
+	for _, preview := range previews {
+		fields := make([]interface{}, 0, len(preview.Fields))
+		for _, f := range preview.Fields {
+			fields = append(fields, map[string]interface{}{
+				"field_name":   f.FieldName,
+				"display_name": f.DisplayName,
+				"value":        f.Value,
+			})
+		}
+		states = append(states, map[string]interface{}{
+			"state":   preview.State,
+			"subject": preview.Subject,
+			"body":    preview.Body,
+			"fields":  fields,
+		})
+	}
+
+	aliases := make([]string, 0, len(unknown))
+	for _, u := range unknown {
+		aliases = append(aliases, u.Alias)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unknown notification template alias",
+			Detail: fmt.Sprintf(
+				"Alias %q in %s is not an alert field, so it is not filled in.",
+				u.Alias, strings.Join(u.Fields, ", "),
+			),
+		})
+	}
+
+	b, err := json.Marshal(previews)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256(b)))
+	d.Set("integration_type", integrationType)
+	if err := d.Set("states", states); err != nil {
+		log.Printf("[WARN] Error setting 'states' for %q: %s", d.Id(), err)
+	}
+	if err := d.Set("unknown_aliases", aliases); err != nil {
+		log.Printf("[WARN] Error setting 'unknown_aliases' for %q: %s", d.Id(), err)
+	}
+
+	return diags
+}
@@ -1,19 +1,49 @@
 package prismacloud
 
 import (
-	"bytes"
 	"encoding/base64"
-	"fmt"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strconv"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"golang.org/x/net/context"
-	"log"
+	"golang.org/x/sync/errgroup"
 
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/policy"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// policyFilterParams maps the typed filter params to v2/policy query params.
+var policyFilterParams = []struct {
+	key   string
+	param string
+}{
+	{"names", "policy.name"},
+	{"severities", "policy.severity"},
+	{"labels", "policy.label"},
+	{"cloud_types", "cloud.type"},
+	{"compliance_standards", "policy.complianceStandard"},
+	{"compliance_requirements", "policy.complianceRequirement"},
+	{"compliance_sections", "policy.complianceSection"},
+	{"policy_types", "policy.type"},
+	{"policy_subtypes", "policy.subtype"},
+	{"policy_modes", "policy.policyMode"},
+}
+
+// policyFilterBools are the typed bool filter params.
+var policyFilterBools = []struct {
+	key   string
+	param string
+}{
+	{"enabled", "policy.enabled"},
+	{"remediable", "policy.remediable"},
+}
+
 func dataSourcePolicies() *schema.Resource {
 	return &schema.Resource{
 		ReadContext: dataSourcePoliciesRead,
@@ -28,6 +58,69 @@ func dataSourcePolicies() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"names": policyFilterSchema("Policy names"),
+			"severities": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Severities",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+					ValidateFunc: validation.StringInSlice(
+						[]string{
+							policy.SeverityLow,
+							policy.SeverityMedium,
+							policy.SeverityHigh,
+							policy.SeverityCritical,
+							policy.SeverityInformational,
+						},
+						false,
+					),
+				},
+			},
+			"labels":                  policyFilterSchema("Labels"),
+			"cloud_types":             policyFilterSchema("Cloud types"),
+			"compliance_standards":    policyFilterSchema("Compliance standard names"),
+			"compliance_requirements": policyFilterSchema("Compliance requirement names"),
+			"compliance_sections":     policyFilterSchema("Compliance section IDs"),
+			"policy_types":            policyFilterSchema("Policy types"),
+			"policy_subtypes":         policyFilterSchema("Policy subtypes"),
+			"policy_modes": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Policy modes",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+					ValidateFunc: validation.StringInSlice(
+						[]string{
+							"redlock_default",
+							"custom",
+						},
+						false,
+					),
+				},
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Only enabled (or disabled) policies",
+			},
+			"remediable": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Only remediable (or non-remediable) policies",
+			},
+			"detailed": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Fetch the rule, remediation and compliance metadata of each policy",
+			},
+			"detail_concurrency": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				Description:  "Number of policies fetched at once in detailed mode",
+				ValidateFunc: validation.IntBetween(1, 16),
+			},
 
 			// Output.
 			"total": totalSchema("policies"),
@@ -123,6 +216,123 @@ func dataSourcePolicies() *schema.Resource {
 							Computed:    true,
 							Description: "Remediable",
 						},
+
+						// Detailed mode only.
+						"rule": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Rule",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Name",
+									},
+									"rule_type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Rule type",
+									},
+									"criteria": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Criteria",
+									},
+									"cloud_type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Cloud type",
+									},
+									"api_name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "API name",
+									},
+									"resource_type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Resource type",
+									},
+									"parameters": {
+										Type:        schema.TypeMap,
+										Computed:    true,
+										Description: "Parameters",
+										Elem: &schema.Schema{
+											Type: schema.TypeString,
+										},
+									},
+								},
+							},
+						},
+						"remediation": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Remediation",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"template_type": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Template type",
+									},
+									"description": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Description",
+									},
+									"cli_script_template": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "CLI script template",
+									},
+									"cli_script_json_schema_string": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "CLI script JSON schema",
+									},
+								},
+							},
+						},
+						"compliance_metadata": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "Compliance metadata",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"compliance_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Compliance section UUID",
+									},
+									"standard_name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Compliance standard name",
+									},
+									"requirement_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Requirement ID",
+									},
+									"requirement_name": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Requirement name",
+									},
+									"section_id": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Section ID",
+									},
+									"section_label": {
+										Type:        schema.TypeString,
+										Computed:    true,
+										Description: "Section label",
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -130,39 +340,185 @@ func dataSourcePolicies() *schema.Resource {
 	}
 }
 
-func dataSourcePoliciesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var buf bytes.Buffer
-	client := meta.(*pc.Client)
+func policyFilterSchema(desc string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Description: desc,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+}
+
+// policiesQuery builds the v2/policy query params.  A filter given more than
+// once matches any of its values.
+func policiesQuery(d *schema.ResourceData) url.Values {
+	query := url.Values{}
+
+	for key, value := range d.Get("filters").(map[string]interface{}) {
+		query.Add(key, value.(string))
+	}
+
+	for _, f := range policyFilterParams {
+		for _, v := range SetToStringSlice(d.Get(f.key).(*schema.Set)) {
+			query.Add(f.param, v)
+		}
+	}
+
+	raw := d.GetRawConfig()
+	for _, f := range policyFilterBools {
+		if !raw.IsNull() && !raw.GetAttr(f.key).IsNull() {
+			query.Set(f.param, strconv.FormatBool(d.Get(f.key).(bool)))
+		}
+	}
+
+	return query
+}
+
+// listPolicies is policy.List, but allows a query param more than once.
+func listPolicies(c pc.PrismaCloudClient, query url.Values) ([]policy.Policy, error) {
+	c.Log(pc.LogAction, "(get) list of policies")
+
+	var ans []policy.Policy
+	_, err := c.Communicate("GET", []string{"v2", "policy"}, query, nil, &ans)
+
+	return ans, err
+}
+
+// getPolicies fetches the full body of the given policies, a few at a time.
+func getPolicies(ctx context.Context, client *pc.Client, ids []string, concurrency int) ([]policy.Policy, error) {
+	return fetchPolicies(ctx, ids, concurrency, func(id string) (policy.Policy, error) {
+		var obj policy.Policy
+		var lastErr error
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			obj, err = policy.Get(client, id)
+			lastErr = err
+			return err
+		}); diags != nil {
+			return obj, lastErr
+		}
+		return obj, nil
+	})
+}
+
+// fetchPolicies calls get for each ID, at most concurrency at a time.  Once
+// one fails, or ctx is done, the policies not yet started are skipped.
+func fetchPolicies(ctx context.Context, ids []string, concurrency int, get func(string) (policy.Policy, error)) ([]policy.Policy, error) {
+	ans := make([]policy.Policy, len(ids))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, id := range ids {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			var err error
+			ans[i], err = get(id)
+			return err
+		})
+	}
+
+	return ans, g.Wait()
+}
+
+func flattenPolicyDetails(obj policy.Policy) map[string]interface{} {
+	var criteria string
+	switch v := obj.Rule.Criteria.(type) {
+	case string:
+		criteria = v
+	case nil:
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			log.Printf("[WARN] Failed to marshal criteria for %q: %s", obj.PolicyId, err)
+		}
+		criteria = string(b)
+	}
+
+	rule := map[string]interface{}{
+		"name":          obj.Rule.Name,
+		"rule_type":     obj.Rule.Type,
+		"criteria":      criteria,
+		"cloud_type":    obj.Rule.CloudType,
+		"api_name":      obj.Rule.ApiName,
+		"resource_type": obj.Rule.ResourceType,
+		"parameters":    obj.Rule.Parameters,
+	}
 
-	filters := d.Get("filters").(map[string]interface{})
-	query := make(map[string]string)
-	for key := range filters {
-		if buf.Len() > 0 {
-			buf.WriteString("&")
+	var csjs string
+	if obj.Remediation.CliScriptJsonSchema != nil {
+		b, err := json.Marshal(obj.Remediation.CliScriptJsonSchema)
+		if err != nil {
+			log.Printf("[WARN] Failed to marshal cli script json schema for %q: %s", obj.PolicyId, err)
 		}
-		query[key] = filters[key].(string)
-		buf.WriteString(fmt.Sprintf("%s=%s", key, query[key]))
+		csjs = string(b)
+	}
+	rem := map[string]interface{}{
+		"template_type":                 obj.Remediation.TemplateType,
+		"description":                   obj.Remediation.Description,
+		"cli_script_template":           obj.Remediation.CliScriptTemplate,
+		"cli_script_json_schema_string": csjs,
+	}
+
+	cms := make([]interface{}, 0, len(obj.ComplianceMetadata))
+	for _, o := range obj.ComplianceMetadata {
+		cms = append(cms, map[string]interface{}{
+			"compliance_id":    o.ComplianceId,
+			"standard_name":    o.StandardName,
+			"requirement_id":   o.RequirementId,
+			"requirement_name": o.RequirementName,
+			"section_id":       o.SectionId,
+			"section_label":    o.SectionLabel,
+		})
+	}
+
+	return map[string]interface{}{
+		"rule":                []interface{}{rule},
+		"remediation":         []interface{}{rem},
+		"compliance_metadata": cms,
 	}
+}
+
+func dataSourcePoliciesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	query := policiesQuery(d)
+	detailed := d.Get("detailed").(bool)
 
 	var items []policy.Policy
 	if diags := RetryWithBackoff(client, func() error {
 		var err error
-		items, err = policy.List(client, query)
+		items, err = listPolicies(client, query)
 		return err
 	}); diags != nil {
 		return diags
 	}
 
-	if buf.Len() == 0 {
+	var details []policy.Policy
+	if detailed {
+		ids := make([]string, 0, len(items))
+		for _, i := range items {
+			ids = append(ids, i.PolicyId)
+		}
+
+		var err error
+		if details, err = getPolicies(ctx, client, ids, d.Get("detail_concurrency").(int)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if len(query) == 0 && !detailed {
 		d.SetId("all")
 	} else {
-		d.SetId(base64.StdEncoding.EncodeToString(buf.Bytes()))
+		d.SetId(base64.StdEncoding.EncodeToString([]byte(query.Encode() + "&detailed=" + strconv.FormatBool(detailed))))
 	}
 	d.Set("total", len(items))
 
 	list := make([]interface{}, 0, len(items))
-	for _, i := range items {
-		list = append(list, map[string]interface{}{
+	for idx, i := range items {
+		item := map[string]interface{}{
 			"policy_id":         i.PolicyId,
 			"name":              i.Name,
 			"policy_type":       i.PolicyType,
@@ -179,7 +535,13 @@ func dataSourcePoliciesRead(ctx context.Context, d *schema.ResourceData, meta in
 			"open_alerts_count": i.OpenAlertsCount,
 			"policy_mode":       i.PolicyMode,
 			"remediable":        i.Remediable,
-		})
+		}
+		if detailed {
+			for k, v := range flattenPolicyDetails(details[idx]) {
+				item[k] = v
+			}
+		}
+		list = append(list, item)
 	}
 
 	if err := d.Set("listing", list); err != nil {
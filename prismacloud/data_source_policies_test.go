@@ -1,15 +1,24 @@
 package prismacloud
 
 import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"golang.org/x/net/context"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
 func TestAccDsPolicies(t *testing.T) {
 	resource.Test(t, resource.TestCase{
-		PreCheck:  func() { testAccPreCheck(t) },
-		Providers: testAccProviders,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDsPoliciesConfig(),
@@ -26,3 +35,138 @@ func testAccDsPoliciesConfig() string {
 data "prismacloud_policies" "test" {}
 `
 }
+
+func TestPoliciesQuery(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+		want url.Values
+	}{
+		{"empty", map[string]interface{}{}, url.Values{}},
+		{
+			"filters",
+			map[string]interface{}{"filters": map[string]interface{}{"policy.name": "foo", "cloud.type": "aws"}},
+			url.Values{"policy.name": {"foo"}, "cloud.type": {"aws"}},
+		},
+		{
+			"repeated params",
+			map[string]interface{}{
+				"severities":  []interface{}{"high", "low"},
+				"cloud_types": []interface{}{"aws"},
+				"labels":      []interface{}{"a b", "c"},
+			},
+			url.Values{"policy.severity": {"high", "low"}, "cloud.type": {"aws"}, "policy.label": {"a b", "c"}},
+		},
+		{
+			"filters and typed params",
+			map[string]interface{}{
+				"filters":    map[string]interface{}{"policy.severity": "critical"},
+				"severities": []interface{}{"high"},
+			},
+			url.Values{"policy.severity": {"critical", "high"}},
+		},
+		{
+			"false is sent",
+			map[string]interface{}{"enabled": false},
+			url.Values{"policy.enabled": {"false"}},
+		},
+		{
+			"typed bool wins",
+			map[string]interface{}{
+				"filters":    map[string]interface{}{"policy.enabled": "false"},
+				"enabled":    true,
+				"remediable": false,
+			},
+			url.Values{"policy.enabled": {"true"}, "policy.remediable": {"false"}},
+		},
+		{
+			"detailed is not a filter",
+			map[string]interface{}{"detailed": true},
+			url.Values{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := policiesQuery(testResourceDataWithConfig(t, dataSourcePolicies().Schema, tc.raw))
+			for _, v := range got {
+				sort.Strings(v)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Got %q (%s), expected %q", got, got.Encode(), tc.want)
+			}
+		})
+	}
+}
+
+func TestPoliciesDetailConcurrency(t *testing.T) {
+	s := dataSourcePolicies().Schema["detail_concurrency"]
+	if s.Default != 4 {
+		t.Errorf("Default is %v, expected 4", s.Default)
+	}
+	for _, tc := range []struct {
+		v  int
+		ok bool
+	}{{0, false}, {1, true}, {16, true}, {17, false}} {
+		if _, errs := s.ValidateFunc(tc.v, "detail_concurrency"); (len(errs) == 0) != tc.ok {
+			t.Errorf("%d: got errors %v", tc.v, errs)
+		}
+	}
+
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p-%02d", i)
+	}
+
+	for _, concurrency := range []int{1, 4, 16} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			var mu sync.Mutex
+			var running, most int
+			got, err := fetchPolicies(context.Background(), ids, concurrency, func(id string) (policy.Policy, error) {
+				mu.Lock()
+				running++
+				if running > most {
+					most = running
+				}
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return policy.Policy{PolicyId: id}, nil
+			})
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			if most > concurrency {
+				t.Errorf("Fetched %d at once, expected at most %d", most, concurrency)
+			}
+			for i, o := range got {
+				if o.PolicyId != ids[i] {
+					t.Fatalf("Policy %d is %q, expected %q", i, o.PolicyId, ids[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFetchPoliciesStopsOnError(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+
+	var fetched []string
+	_, err := fetchPolicies(context.Background(), ids, 1, func(id string) (policy.Policy, error) {
+		fetched = append(fetched, id)
+		if id == "b" {
+			return policy.Policy{}, fmt.Errorf("failed")
+		}
+		return policy.Policy{PolicyId: id}, nil
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("Got error %v, expected the first failure", err)
+	}
+	if !reflect.DeepEqual(fetched, []string{"a", "b"}) {
+		t.Errorf("Fetched %q, expected the fetches after the failure to be skipped", fetched)
+	}
+}
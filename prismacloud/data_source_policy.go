@@ -254,6 +254,7 @@ func dataSourcePolicy() *schema.Resource {
 											Type: schema.TypeString,
 										},
 									},
+									"conditions": buildPolicyConditionsSchema(false),
 									"type": {
 										Type:        schema.TypeString,
 										Computed:    true,
@@ -421,7 +422,7 @@ func dataSourcePolicyRead(ctx context.Context, d *schema.ResourceData, meta inte
 	}
 
 	d.SetId(id)
-	savePolicy(d, obj)
+	savePolicy(d, obj, nil)
 
 	return nil
 }
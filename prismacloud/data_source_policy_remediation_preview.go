@@ -0,0 +1,132 @@
+package prismacloud
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourcePolicyRemediationPreview() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourcePolicyRemediationPreviewRead,
+
+		Schema: map[string]*schema.Schema{
+			// Input.
+			"policy_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Take the remediation from this policy",
+				ExactlyOneOf: []string{"policy_id", "cli_script_template"},
+			},
+			"cli_script_template": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "CLI script template",
+			},
+			"cli_script_json_schema_string": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				Description:   "CLI script JSON schema",
+				ValidateFunc:  validateCliScriptJsonSchema,
+				ConflictsWith: []string{"policy_id"},
+			},
+			"resource_json": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Sample resource, as JSON",
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"parameters": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Values of the JSON schema properties",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+
+			// Output.
+			"placeholders": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Placeholders in the template",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"command": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The rendered remediation command",
+			},
+		},
+	}
+}
+
+func dataSourcePolicyRemediationPreviewRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	template := d.Get("cli_script_template").(string)
+	jsonSchema := d.Get("cli_script_json_schema_string").(string)
+
+	if id := d.Get("policy_id").(string); id != "" {
+		client := meta.(*pc.Client)
+
+		var obj policy.Policy
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			obj, err = policy.Get(client, id)
+			return err
+		}); diags != nil {
+			return diags
+		}
+
+		if obj.Remediation.CliScriptTemplate == "" {
+			return diag.Errorf("Policy %q has no CLI remediation", id)
+		}
+		template = obj.Remediation.CliScriptTemplate
+		jsonSchema = ""
+		if obj.Remediation.CliScriptJsonSchema != nil {
+			b, err := json.Marshal(obj.Remediation.CliScriptJsonSchema)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			jsonSchema = string(b)
+		}
+	}
+
+	var resource map[string]interface{}
+	if err := json.Unmarshal([]byte(d.Get("resource_json").(string)), &resource); err != nil {
+		return diag.Errorf("resource_json must be a JSON object: %s", err)
+	}
+
+	params := make(map[string]string)
+	for key, val := range d.Get("parameters").(map[string]interface{}) {
+		params[key] = val.(string)
+	}
+
+	command, err := renderCliScript(template, jsonSchema, resource, params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(template+jsonSchema+command))))
+	d.Set("cli_script_template", template)
+	d.Set("cli_script_json_schema_string", jsonSchema)
+	d.Set("command", command)
+	if err := d.Set("placeholders", cliScriptPlaceholders(template)); err != nil {
+		log.Printf("[WARN] Error setting 'placeholders' for %q: %s", d.Id(), err)
+	}
+
+	return nil
+}
@@ -160,6 +160,7 @@ func dataSourceReport() *schema.Resource {
 							Computed:    true,
 							Description: "Recurring report schedule in RRULE format",
 						},
+						"next_runs": nextRunsSchema("the report is scheduled"),
 						"notification_template_id": {
 							Type:        schema.TypeString,
 							Computed:    true,
@@ -0,0 +1,508 @@
+package prismacloud
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/search"
+	"github.com/paloaltonetworks/prisma-cloud-go/timerange"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// rqlQueryPageSize is the most results asked for in a single search request.
+// Network and asset searches can't be paged, so they return at most this many.
+const rqlQueryPageSize = 1000
+
+func dataSourceRqlQuery() *schema.Resource {
+	rs := resourceRqlSearch().Schema
+
+	ans := &schema.Resource{
+		ReadContext: dataSourceRqlQueryRead,
+
+		Schema: map[string]*schema.Schema{
+			// Input.
+			"search_type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The search type",
+				Default:     "config",
+				ValidateFunc: validation.StringInSlice([]string{
+					"config",
+					"network",
+					"event",
+					"iam",
+					"asset",
+				}, false),
+			},
+			"query": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "The RQL query to run",
+				ValidateFunc: validateRql,
+			},
+			"time_range": timeRangeSchema("data_source_rql_query"),
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Maximum number of results",
+				Default:      100,
+				ValidateFunc: validation.IntBetween(1, 10000),
+			},
+			"heuristic_search": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Enable heuristic search",
+			},
+
+			// Output.
+			"search_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The search ID",
+			},
+			"result_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of results returned",
+			},
+			"total_rows": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Total number of results, if known",
+			},
+			"truncated": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "If there are more results than limit",
+			},
+			"group_by":     rs["group_by"],
+			"config_data":  rs["config_data"],
+			"event_data":   rs["event_data"],
+			"network_data": rs["network_data"],
+			"iam_data":     rs["iam_data"],
+			"asset_data":   rs["asset_data"],
+		},
+	}
+
+	addOutputFileSchema(ans.Schema)
+
+	return ans
+}
+
+// rqlPage is a page of search results, with the paging info that the
+// search package's responses leave out.
+type rqlPage[T any] struct {
+	Items         []T    `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+	TotalRows     int    `json:"totalRows"`
+}
+
+// rqlConfigPageResponse is a config search response.  T is
+// search.ConfigItem, or an item with the resource JSON as well.
+type rqlConfigPageResponse[T any] struct {
+	search.ConfigResponse
+	Data rqlPage[T] `json:"data"`
+}
+
+type rqlEventPageResponse struct {
+	search.EventResponse
+	Data rqlPage[search.EventItem] `json:"data"`
+}
+
+type rqlIamPageResponse struct {
+	search.IamResponse
+	Data rqlPage[search.IamItem] `json:"data"`
+}
+
+// readRqlPages passes the items of the first page to each, then gets the
+// next pages with next until limit items are read.  It returns the number of
+// items read and if there are more.
+func readRqlPages[T any](first rqlPage[T], limit int, next func(token string, n int) (rqlPage[T], error), each func([]T) error) (int, bool, error) {
+	var count int
+	page := first
+	for {
+		items := page.Items
+		if len(items) > limit-count {
+			items = items[:limit-count]
+		}
+		if err := each(items); err != nil {
+			return count, false, err
+		}
+		count += len(items)
+
+		if len(items) < len(page.Items) {
+			return count, true, nil
+		}
+		if page.NextPageToken == "" || count >= limit || len(page.Items) == 0 {
+			break
+		}
+
+		var err error
+		if page, err = next(page.NextPageToken, min(limit-count, rqlQueryPageSize)); err != nil {
+			return count, false, err
+		}
+	}
+
+	return count, page.NextPageToken != "" || first.TotalRows > count, nil
+}
+
+// rqlPageReader returns the next func of readRqlPages for the page API at
+// the given path.
+func rqlPageReader[T any](client *pc.Client, path ...string) func(string, int) (rqlPage[T], error) {
+	return func(token string, n int) (rqlPage[T], error) {
+		req := map[string]interface{}{
+			"pageToken": token,
+			"limit":     n,
+		}
+
+		var page rqlPage[T]
+		var lastErr error
+		if diags := RetryWithBackoff(client, func() error {
+			_, err := client.Communicate("POST", path, nil, req, &page)
+			lastErr = err
+			return err
+		}); diags != nil {
+			return page, lastErr
+		}
+		return page, nil
+	}
+}
+
+// runRqlQuery posts the first search request, returning its response.
+func runRqlQuery(client *pc.Client, path []string, req, ans interface{}) error {
+	var lastErr error
+	if diags := RetryWithBackoff(client, func() error {
+		_, err := client.Communicate("POST", path, nil, req, ans)
+		lastErr = err
+		return err
+	}); diags != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// rqlPageHandler returns the each func of readRqlPages: it passes each page
+// to each, or if each is nil, collects the items in items.
+func rqlPageHandler[T any](items *[]T, each func(interface{}) error) func([]T) error {
+	if each != nil {
+		return func(list []T) error {
+			return each(list)
+		}
+	}
+
+	return func(list []T) error {
+		*items = append(*items, list...)
+		return nil
+	}
+}
+
+// runRqlConfigQuery runs a config search, following its pages until limit
+// results are read.  Each page is passed to each, or if each is nil, the
+// results are collected in the response's items.  It returns the number of
+// results read and if there are more.
+func runRqlConfigQuery[T any](client *pc.Client, req search.ConfigRequest, limit int, each func(interface{}) error) (rqlConfigPageResponse[T], int, bool, error) {
+	req.Limit = min(limit, rqlQueryPageSize)
+
+	var ans rqlConfigPageResponse[T]
+	if err := req.TimeRange.SetType(); err != nil {
+		return ans, 0, false, err
+	}
+	if err := runRqlQuery(client, []string{"search", "config"}, req, &ans); err != nil {
+		return ans, 0, false, err
+	}
+
+	var items []T
+	count, more, err := readRqlPages(ans.Data, limit, rqlPageReader[T](client, "search", "config", "page"), rqlPageHandler(&items, each))
+	ans.Data.Items = items
+
+	return ans, count, more, err
+}
+
+// rqlResults are the results of a search.  Items is a slice of the search
+// type's items, such as []search.ConfigItem.
+type rqlResults struct {
+	Id          string
+	CloudType   string
+	Name        string
+	Description string
+	GroupBy     []string
+	Items       interface{}
+	Count       int
+	TotalRows   int
+	Truncated   bool
+}
+
+// queryRql runs a search (or reruns the search with the given ID) and reads
+// up to limit results.  Each page of results is passed to each as it is read,
+// or if each is nil, the results are collected in Items.
+func queryRql(client *pc.Client, searchType, searchId, query string, tr timerange.TimeRange, limit int, heuristicSearch bool, each func(interface{}) error) (rqlResults, error) {
+	var ans rqlResults
+	var lastErr error
+
+	switch searchType {
+	case "config":
+		resp, count, more, err := runRqlConfigQuery[search.ConfigItem](client, search.ConfigRequest{
+			Id:              searchId,
+			Query:           query,
+			TimeRange:       tr,
+			HeuristicSearch: heuristicSearch,
+		}, limit, each)
+		if err != nil {
+			return ans, err
+		}
+
+		ans = rqlResults{
+			Id:          resp.Id,
+			CloudType:   resp.CloudType,
+			Name:        resp.Name,
+			Description: resp.Description,
+			GroupBy:     resp.GroupBy,
+			Items:       resp.Data.Items,
+			Count:       count,
+			TotalRows:   resp.Data.TotalRows,
+			Truncated:   more,
+		}
+	case "network":
+		limit = min(limit, rqlQueryPageSize)
+		var resp search.NetworkResponse
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			resp, err = search.NetworkSearch(client, search.NetworkRequest{
+				Id:        searchId,
+				Query:     query,
+				Limit:     limit,
+				TimeRange: tr,
+			})
+			lastErr = err
+			return err
+		}); diags != nil {
+			return ans, lastErr
+		}
+
+		ans = rqlResults{
+			Id:          resp.Id,
+			CloudType:   resp.CloudType,
+			Name:        resp.Name,
+			Description: resp.Description,
+			GroupBy:     resp.GroupBy,
+			Items:       resp.Data.Items,
+			Count:       len(resp.Data.Items),
+			Truncated:   len(resp.Data.Items) >= limit,
+		}
+	case "event":
+		req := search.EventRequest{
+			Id:              searchId,
+			Query:           query,
+			Limit:           min(limit, rqlQueryPageSize),
+			TimeRange:       tr,
+			HeuristicSearch: heuristicSearch,
+		}
+		if err := req.TimeRange.SetType(); err != nil {
+			return ans, err
+		}
+
+		var resp rqlEventPageResponse
+		if err := runRqlQuery(client, []string{"search", "event"}, req, &resp); err != nil {
+			return ans, err
+		}
+
+		var items []search.EventItem
+		count, more, err := readRqlPages(resp.Data, limit, rqlPageReader[search.EventItem](client, "search", "event", "page"), rqlPageHandler(&items, each))
+		if err != nil {
+			return ans, err
+		}
+
+		ans = rqlResults{
+			Id:          resp.Id,
+			CloudType:   resp.CloudType,
+			Name:        resp.Name,
+			Description: resp.Description,
+			GroupBy:     resp.GroupBy,
+			Items:       items,
+			Count:       count,
+			TotalRows:   resp.Data.TotalRows,
+			Truncated:   more,
+		}
+	case "iam":
+		req := search.IamRequest{
+			Id:    searchId,
+			Query: query,
+			Limit: min(limit, rqlQueryPageSize),
+		}
+
+		var resp rqlIamPageResponse
+		if err := runRqlQuery(client, search.IamSuffix, req, &resp); err != nil {
+			return ans, err
+		}
+
+		var items []search.IamItem
+		count, more, err := readRqlPages(resp.Data, limit, rqlPageReader[search.IamItem](client, "api", "v1", "permission", "page"), rqlPageHandler(&items, each))
+		if err != nil {
+			return ans, err
+		}
+
+		ans = rqlResults{
+			Id:          resp.Id,
+			Name:        resp.Name,
+			Description: resp.Description,
+			Items:       items,
+			Count:       count,
+			TotalRows:   resp.Data.TotalRows,
+			Truncated:   more,
+		}
+	case "asset":
+		limit = min(limit, rqlQueryPageSize)
+		var resp search.AssetResponse
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			resp, err = search.AssetSearch(client, search.AssetRequest{
+				SavedSearchId: searchId,
+				Query:         query,
+				Limit:         limit,
+			})
+			lastErr = err
+			return err
+		}); diags != nil {
+			return ans, lastErr
+		}
+
+		// Asset searches don't say if there are more, so a full page may
+		// mean there is.
+		ans = rqlResults{
+			Id:        resp.ResultMetadata.SearchId,
+			CloudType: resp.ResultMetadata.CloudType,
+			Items:     resp.Value,
+			Count:     len(resp.Value),
+			Truncated: len(resp.Value) >= limit,
+		}
+	default:
+		return ans, fmt.Errorf("unknown search type %q", searchType)
+	}
+
+	// Network and asset results come in a single page.
+	if each != nil && (searchType == "network" || searchType == "asset") {
+		if err := each(ans.Items); err != nil {
+			return ans, err
+		}
+		ans.Items = nil
+	}
+
+	return ans, nil
+}
+
+// rqlResultSample is a zero value result of the search type.
+func rqlResultSample(searchType string) interface{} {
+	switch searchType {
+	case "config":
+		return search.ConfigItem{}
+	case "network":
+		return search.NetworkItem{}
+	case "event":
+		return search.EventItem{}
+	case "iam":
+		return search.IamItem{}
+	case "asset":
+		return search.AssetValue{}
+	}
+	return nil
+}
+
+// flattenRqlResults returns the results as the search type's "*_data" param.
+func flattenRqlResults(items interface{}) (string, []interface{}) {
+	switch x := items.(type) {
+	case []search.ConfigItem:
+		return "config_data", flattenRqlConfigItems(x)
+	case []search.NetworkItem:
+		return "network_data", flattenRqlNetworkItems(x)
+	case []search.EventItem:
+		return "event_data", flattenRqlEventItems(x)
+	case []search.IamItem:
+		return "iam_data", flattenRqlIamItems(x)
+	case []search.AssetValue:
+		return "asset_data", flattenRqlAssetItems(x)
+	}
+	return "", nil
+}
+
+// exportRqlResults runs the search with run, writing each page of results
+// to the output file as it is read.
+func exportRqlResults(d *schema.ResourceData, searchType string, run func(each func(interface{}) error) (rqlResults, error)) (rqlResults, diag.Diagnostics) {
+	out, err := createResultFile(d.Get("output_file").(string), d.Get("output_format").(string), rqlResultSample(searchType))
+	if err != nil {
+		return rqlResults{}, diag.FromErr(err)
+	}
+
+	res, err := run(out.WriteAll)
+	if err != nil {
+		out.Abort()
+		return res, diag.FromErr(err)
+	}
+	if err = out.Close(); err != nil {
+		return res, diag.FromErr(err)
+	}
+
+	out.Save(d)
+	return res, nil
+}
+
+// rqlDataKeys are the results params of each search type.
+var rqlDataKeys = []string{"config_data", "event_data", "network_data", "iam_data", "asset_data"}
+
+func dataSourceRqlQueryRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	query := d.Get("query").(string)
+	searchType := d.Get("search_type").(string)
+
+	if err := checkRqlSearchType(query, searchType); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var tr timerange.TimeRange
+	if searchType != "iam" && searchType != "asset" {
+		tr = rqlTimeRange(d.Get("time_range"))
+	}
+
+	run := func(each func(interface{}) error) (rqlResults, error) {
+		return queryRql(client, searchType, "", query, tr, d.Get("limit").(int), d.Get("heuristic_search").(bool), each)
+	}
+
+	var res rqlResults
+	data := make(map[string][]interface{})
+	if d.Get("output_file").(string) != "" {
+		var diags diag.Diagnostics
+		if res, diags = exportRqlResults(d, searchType, run); diags.HasError() {
+			return diags
+		}
+	} else {
+		var err error
+		if res, err = run(nil); err != nil {
+			return diag.FromErr(err)
+		}
+		key, list := flattenRqlResults(res.Items)
+		data[key] = list
+		clearOutputFile(d)
+	}
+
+	d.SetId(buildRqlSearchId(searchType, query, res.Id))
+	d.Set("search_id", res.Id)
+	d.Set("result_count", res.Count)
+	d.Set("total_rows", res.TotalRows)
+	d.Set("truncated", res.Truncated)
+	if err := d.Set("group_by", res.GroupBy); err != nil {
+		log.Printf("[WARN] Error setting 'group_by' for %q: %s", d.Id(), err)
+	}
+
+	for _, key := range rqlDataKeys {
+		if err := d.Set(key, data[key]); err != nil {
+			log.Printf("[WARN] Error setting %q for %q: %s", key, d.Id(), err)
+		}
+	}
+
+	return nil
+}
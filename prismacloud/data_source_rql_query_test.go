@@ -0,0 +1,159 @@
+package prismacloud
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/search"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestReadRqlPages(t *testing.T) {
+	// Ten items, served three at a time.
+	all := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	pageOf := func(start int) rqlPage[int] {
+		end := min(start+3, len(all))
+		page := rqlPage[int]{Items: all[start:end]}
+		if end < len(all) {
+			page.NextPageToken = fmt.Sprint(end)
+		}
+		return page
+	}
+
+	cases := []struct {
+		name  string
+		limit int
+		want  []int
+		more  bool
+		pages int
+	}{
+		{"all", 20, all, false, 3},
+		{"page boundary", 6, all[:6], true, 1},
+		{"within a page", 4, all[:4], true, 1},
+		{"first page", 2, all[:2], true, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var pages int
+			next := func(token string, n int) (rqlPage[int], error) {
+				pages++
+				if n > tc.limit {
+					t.Errorf("Asked for %d items, more than the limit %d", n, tc.limit)
+				}
+				var start int
+				fmt.Sscan(token, &start)
+				return pageOf(start), nil
+			}
+
+			var got []int
+			count, more, err := readRqlPages(pageOf(0), tc.limit, next, func(list []int) error {
+				got = append(got, list...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) || count != len(tc.want) {
+				t.Errorf("Got %v (%d), expected %v", got, count, tc.want)
+			}
+			if more != tc.more {
+				t.Errorf("More is %t, expected %t", more, tc.more)
+			}
+			if pages != tc.pages {
+				t.Errorf("Read %d more pages, expected %d", pages, tc.pages)
+			}
+		})
+	}
+
+	// Without a page token, the total tells if there are more.
+	_, more, _ := readRqlPages(rqlPage[int]{Items: all[:3], TotalRows: 10}, 5, nil, func([]int) error { return nil })
+	if !more {
+		t.Errorf("Expected more results when the total is larger")
+	}
+}
+
+func TestExportRqlResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	d := schema.TestResourceDataRaw(t, dataSourceRqlQuery().Schema, map[string]interface{}{
+		"query":       "config from cloud.resource where api.name = 'aws-ec2-describe-instances'",
+		"output_file": path,
+	})
+
+	// The search passes its pages one at a time.
+	pages := [][]search.ConfigItem{
+		{{Name: "a"}},
+		{{Name: "b"}, {Name: "c"}},
+	}
+	res, diags := exportRqlResults(d, "config", func(each func(interface{}) error) (rqlResults, error) {
+		var count int
+		for _, page := range pages {
+			if err := each(page); err != nil {
+				return rqlResults{}, err
+			}
+			count += len(page)
+		}
+		return rqlResults{Id: "s", Count: count}, nil
+	})
+	if diags.HasError() {
+		t.Fatalf("Unexpected error: %v", diags)
+	}
+	if res.Id != "s" || res.Count != 3 {
+		t.Errorf("Got results %#v", res)
+	}
+	if got := d.Get("output_count").(int); got != 3 {
+		t.Errorf("Output count is %d, expected 3", got)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading result file: %s", err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines != 3 {
+		t.Errorf("Result file has %d lines, expected 3", lines)
+	}
+
+	// A failed search leaves the file as it was.
+	_, diags = exportRqlResults(d, "config", func(each func(interface{}) error) (rqlResults, error) {
+		each(pages[0])
+		return rqlResults{}, fmt.Errorf("page 2 failed")
+	})
+	if !diags.HasError() {
+		t.Fatalf("Expected the search error")
+	}
+	if b2, _ := os.ReadFile(path); string(b2) != string(b) {
+		t.Errorf("Failed search changed the result file")
+	}
+}
+
+func TestAccDsRqlQuery(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDsRqlQueryConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.prismacloud_rql_query.test", "search_id"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_rql_query.test", "result_count"),
+					resource.TestCheckResourceAttrSet("data.prismacloud_rql_query.test", "truncated"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDsRqlQueryConfig() string {
+	return `
+data "prismacloud_rql_query" "test" {
+    query = "config from cloud.resource where api.name = 'aws-ec2-describe-instances'"
+    limit = 5
+}
+`
+}
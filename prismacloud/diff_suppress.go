@@ -0,0 +1,90 @@
+package prismacloud
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Several params hold a serialized JSON or YAML document as a string.  The API
+hands these back re-serialized, so key order and whitespace rarely match what
+the user wrote.  The functions below compare the documents instead of the
+strings.
+
+Anything that does not parse is compared as a plain string, so these are safe
+to put on params that only sometimes carry a document (such as a policy's
+rule criteria, which is usually a saved search ID).
+
+There are no StateFuncs to go with these.  With DiffSuppressOnRefresh set, a
+refresh that only reformats a document keeps the value in state, so state
+already holds what the user wrote and there is nothing left to normalize.
+*/
+
+// normalizeJson returns the canonical (compact, sorted keys) form of s.
+func normalizeJson(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s, err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return s, err
+	}
+
+	return string(b), nil
+}
+
+// normalizeYaml returns the canonical (sorted keys, default indentation) form of s.
+func normalizeYaml(s string) (string, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return s, err
+	}
+
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return s, err
+	}
+
+	return string(b), nil
+}
+
+// suppressEquivalentJsonDiffs is a DiffSuppressFunc for JSON documents.
+func suppressEquivalentJsonDiffs(k, old, new string, d *schema.ResourceData) bool {
+	return equivalentDocuments(old, new, normalizeJson)
+}
+
+// suppressEquivalentYamlDiffs is a DiffSuppressFunc for YAML documents.
+func suppressEquivalentYamlDiffs(k, old, new string, d *schema.ResourceData) bool {
+	return equivalentDocuments(old, new, normalizeYaml)
+}
+
+func equivalentDocuments(old, new string, normalize func(string) (string, error)) bool {
+	if old == new {
+		return true
+	}
+
+	a, err := normalize(old)
+	if err != nil {
+		return false
+	}
+
+	b, err := normalize(new)
+	if err != nil {
+		return false
+	}
+
+	return a == b
+}
@@ -0,0 +1,57 @@
+package prismacloud
+
+import (
+	"testing"
+)
+
+func TestSuppressEquivalentJsonDiffs(t *testing.T) {
+	cases := []struct {
+		old, new string
+		want     bool
+	}{
+		{`{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":1}`, true},
+		{"{\n  \"a\": {\"c\": true, \"b\": null}\n}", `{"a":{"b":null,"c":true}}`, true},
+		{`{"a": 1}`, `{"a": 2}`, false},
+		{`{"b": [1, 2]}`, `{"b": [2, 1]}`, false},
+		{"", "", true},
+		{"", `{}`, false},
+		{"abc-123", "abc-123", true},
+		{"abc-123", "abc-456", false},
+		{"abc-123", `{"a": 1}`, false},
+	}
+
+	for _, tc := range cases {
+		if got := suppressEquivalentJsonDiffs("k", tc.old, tc.new, nil); got != tc.want {
+			t.Errorf("%q vs %q: got %t, expected %t", tc.old, tc.new, got, tc.want)
+		}
+	}
+}
+
+func TestSuppressEquivalentYamlDiffs(t *testing.T) {
+	cases := []struct {
+		old, new string
+		want     bool
+	}{
+		{
+			"cond_type: attribute\nresource_types:\n- aws_s3_bucket\nattribute: acl\noperator: equals\nvalue: private\n",
+			"attribute: acl\ncond_type: attribute\noperator: equals\nresource_types:\n  - aws_s3_bucket\nvalue: private",
+			true,
+		},
+		{
+			"and:\n  - cond_type: attribute\n    value: 1\n",
+			"and: [{cond_type: attribute, value: 1}]",
+			true,
+		},
+		{"value: private\n", "value: public\n", false},
+		{"value: 1\n", "value: \"1\"\n", false},
+		{"", "", true},
+		{"a: [", "a: [", true},
+		{"a: [", "a: []", false},
+	}
+
+	for _, tc := range cases {
+		if got := suppressEquivalentYamlDiffs("k", tc.old, tc.new, nil); got != tc.want {
+			t.Errorf("%q vs %q: got %t, expected %t", tc.old, tc.new, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package prismacloud
+
+import (
+	"log"
+	"sync"
+)
+
+/*
+mutexKV is a set of named mutexes.
+
+Resources that only own part of a Prisma Cloud object (a single member of a
+group, a single policy on an alert rule, ...) have to read the whole object,
+change their part, and write it back.  Terraform applies independent
+resources in parallel, so every such read-modify-write must hold the lock
+for the object being changed.
+*/
+type mutexKV struct {
+	lock  sync.Mutex
+	store map[string]*sync.Mutex
+}
+
+// Lock locks the mutex for the given key, creating it if needed.
+func (m *mutexKV) Lock(key string) {
+	log.Printf("[DEBUG] Locking %q", key)
+	m.get(key).Lock()
+	log.Printf("[DEBUG] Locked %q", key)
+}
+
+// Unlock unlocks the mutex for the given key.
+func (m *mutexKV) Unlock(key string) {
+	log.Printf("[DEBUG] Unlocking %q", key)
+	m.get(key).Unlock()
+	log.Printf("[DEBUG] Unlocked %q", key)
+}
+
+func (m *mutexKV) get(key string) *sync.Mutex {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mutex, ok := m.store[key]
+	if !ok {
+		mutex = &sync.Mutex{}
+		m.store[key] = mutex
+	}
+
+	return mutex
+}
+
+func newMutexKV() *mutexKV {
+	return &mutexKV{
+		store: make(map[string]*sync.Mutex),
+	}
+}
+
+// objectLocks serializes partial updates of the same object within a run.
+var objectLocks = newMutexKV()
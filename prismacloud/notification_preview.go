@@ -0,0 +1,213 @@
+package prismacloud
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+	notification_template "github.com/paloaltonetworks/prisma-cloud-go/notification-template"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+)
+
+/*
+A notification template's field values may refer to alert fields as
+"<$Alias>", and a field may take its whole value from an alert field named by
+its alias_field.  Prisma Cloud fills these in from the alert when it sends the
+notification.
+*/
+
+var notificationAliasPlaceholder = regexp.MustCompile(`<\$(\w+)>`)
+
+// notificationAliases maps the (lower cased) aliases of the alert fields to
+// their value.
+var notificationAliases = map[string]func(a alert.Alert, p policy.Policy) string{
+	"accountid":            func(a alert.Alert, p policy.Policy) string { return a.Resource.AccountId },
+	"accountname":          func(a alert.Alert, p policy.Policy) string { return a.Resource.Account },
+	"alertid":              func(a alert.Alert, p policy.Policy) string { return a.Id },
+	"alertstatus":          func(a alert.Alert, p policy.Policy) string { return a.Status },
+	"alerttime":            func(a alert.Alert, p policy.Policy) string { return notificationTime(a.AlertTime) },
+	"cloudtype":            func(a alert.Alert, p policy.Policy) string { return a.Resource.CloudType },
+	"dismissalnote":        func(a alert.Alert, p policy.Policy) string { return latestAlertHistory(a).Reason },
+	"dismissedby":          func(a alert.Alert, p policy.Policy) string { return latestAlertHistory(a).ModifiedBy },
+	"firstseen":            func(a alert.Alert, p policy.Policy) string { return notificationTime(a.FirstSeen) },
+	"lastseen":             func(a alert.Alert, p policy.Policy) string { return notificationTime(a.LastSeen) },
+	"policydescription":    func(a alert.Alert, p policy.Policy) string { return p.Description },
+	"policyid":             func(a alert.Alert, p policy.Policy) string { return a.Policy.Id },
+	"policyname":           func(a alert.Alert, p policy.Policy) string { return p.Name },
+	"policyrecommendation": func(a alert.Alert, p policy.Policy) string { return p.Recommendation },
+	"policyseverity":       func(a alert.Alert, p policy.Policy) string { return p.Severity },
+	"policytype":           func(a alert.Alert, p policy.Policy) string { return a.Policy.Type },
+	"resourceid":           func(a alert.Alert, p policy.Policy) string { return a.Resource.Id },
+	"resourcename":         func(a alert.Alert, p policy.Policy) string { return a.Resource.Name },
+	"resourceregion":       func(a alert.Alert, p policy.Policy) string { return a.Resource.Region },
+	"resourcetype":         func(a alert.Alert, p policy.Policy) string { return a.Resource.ResourceType },
+	"riskrating":           func(a alert.Alert, p policy.Policy) string { return a.Risk.Rating },
+}
+
+// notificationStates are the alert states a notification is sent for.
+var notificationStates = []string{"open", "resolved", "dismissed", "snoozed"}
+
+// notificationSubjectBody are the fields that hold the subject and the body
+// of each integration type's notifications.
+var notificationSubjectBody = map[string][2]string{
+	notification_template.Email:      {"subject", "custom_note"},
+	notification_template.Jira:       {"summary", "description"},
+	notification_template.ServiceNow: {"short_description", "description"},
+}
+
+type notificationPreview struct {
+	State   string
+	Subject string
+	Body    string
+	Fields  []notificationPreviewField
+}
+
+type notificationPreviewField struct {
+	FieldName   string
+	DisplayName string
+	Value       string
+}
+
+// notificationUnknownAlias is an alias that is not an alert field, with the
+// fields ("state.field_name") that use it.
+type notificationUnknownAlias struct {
+	Alias  string
+	Fields []string
+}
+
+func notificationTime(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+
+	return time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339)
+}
+
+// latestAlertHistory returns the alert's most recent status change.
+func latestAlertHistory(a alert.Alert) alert.History {
+	var ans alert.History
+	for _, h := range a.History {
+		if h.ModifiedOn >= ans.ModifiedOn {
+			ans = h
+		}
+	}
+
+	return ans
+}
+
+// notificationAliasName returns the alias of an alias_field, which may be
+// given as "Alias", "$Alias" or "<$Alias>".
+func notificationAliasName(s string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "<"), ">"), "$")
+}
+
+// notificationTemplateSections returns the configs of a template by section,
+// in the order they are shown.
+func notificationTemplateSections(conf notification_template.TemplateConfigStruct) ([]string, map[string][]notification_template.Config) {
+	return []string{"basic_config", "open", "resolved", "dismissed", "snoozed"}, map[string][]notification_template.Config{
+		"basic_config": conf.BasicConfig,
+		"open":         conf.Open,
+		"resolved":     conf.Resolved,
+		"dismissed":    conf.Dismissed,
+		"snoozed":      conf.Snoozed,
+	}
+}
+
+// notificationTemplateUnknownAliases returns the aliases of a template that
+// are not alert fields, sorted.
+func notificationTemplateUnknownAliases(conf notification_template.TemplateConfigStruct) []notificationUnknownAlias {
+	unknown := make(map[string][]string)
+	add := func(alias, field string) {
+		if _, ok := notificationAliases[strings.ToLower(alias)]; ok {
+			return
+		}
+		if !stringInSlice(field, unknown[alias]) {
+			unknown[alias] = append(unknown[alias], field)
+		}
+	}
+
+	sections, configs := notificationTemplateSections(conf)
+	for _, section := range sections {
+		for _, c := range configs[section] {
+			field := section + "." + c.FieldName
+			for _, m := range notificationAliasPlaceholder.FindAllStringSubmatch(c.Value, -1) {
+				add(m[1], field)
+			}
+			if alias := notificationAliasName(c.AliasField); alias != "" {
+				add(alias, field)
+			}
+		}
+	}
+
+	ans := make([]notificationUnknownAlias, 0, len(unknown))
+	for alias, fields := range unknown {
+		ans = append(ans, notificationUnknownAlias{Alias: alias, Fields: fields})
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Alias < ans[j].Alias })
+
+	return ans
+}
+
+// renderNotificationValue fills in the aliases of a field from the alert.
+// Unknown aliases are left as is.
+func renderNotificationValue(c notification_template.Config, a alert.Alert, p policy.Policy) string {
+	if c.Value == "" && c.AliasField != "" {
+		if fn, ok := notificationAliases[strings.ToLower(notificationAliasName(c.AliasField))]; ok {
+			return fn(a, p)
+		}
+		return c.Value
+	}
+
+	return notificationAliasPlaceholder.ReplaceAllStringFunc(c.Value, func(s string) string {
+		alias := notificationAliasPlaceholder.FindStringSubmatch(s)[1]
+		if fn, ok := notificationAliases[strings.ToLower(alias)]; ok {
+			return fn(a, p)
+		}
+		return s
+	})
+}
+
+// renderNotificationTemplate renders the notification of each alert state.
+// The basic config applies to every state, and a state's own config overrides
+// basic config fields of the same name.
+func renderNotificationTemplate(integrationType string, conf notification_template.TemplateConfigStruct, a alert.Alert, p policy.Policy) []notificationPreview {
+	_, configs := notificationTemplateSections(conf)
+	subjectBody, hasSubjectBody := notificationSubjectBody[integrationType]
+
+	ans := make([]notificationPreview, 0, len(notificationStates))
+	for _, state := range notificationStates {
+		a.Status = state
+
+		fields := make([]notificationPreviewField, 0, len(conf.BasicConfig)+len(configs[state]))
+		index := make(map[string]int)
+		for _, c := range append(append([]notification_template.Config(nil), conf.BasicConfig...), configs[state]...) {
+			f := notificationPreviewField{
+				FieldName:   c.FieldName,
+				DisplayName: c.DisplayName,
+				Value:       renderNotificationValue(c, a, p),
+			}
+			if i, ok := index[c.FieldName]; ok {
+				fields[i] = f
+				continue
+			}
+			index[c.FieldName] = len(fields)
+			fields = append(fields, f)
+		}
+
+		preview := notificationPreview{State: state, Fields: fields}
+		for _, f := range fields {
+			switch {
+			case !hasSubjectBody:
+			case f.FieldName == subjectBody[0]:
+				preview.Subject = f.Value
+			case f.FieldName == subjectBody[1]:
+				preview.Body = f.Value
+			}
+		}
+		ans = append(ans, preview)
+	}
+
+	return ans
+}
@@ -0,0 +1,129 @@
+package prismacloud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+	notification_template "github.com/paloaltonetworks/prisma-cloud-go/notification-template"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+)
+
+func TestRenderNotificationTemplate(t *testing.T) {
+	a := alert.Alert{
+		Id:        "P-1",
+		Status:    "open",
+		AlertTime: 1700000000000,
+		Policy:    alert.Policy{Id: "pol-1", Type: "config"},
+		Resource: alert.Resource{
+			Id:        "i-123",
+			Name:      "web",
+			Account:   "prod",
+			AccountId: "1234",
+			Region:    "AWS Virginia",
+		},
+		History: []alert.History{
+			{Status: "open", ModifiedOn: 1},
+			{Status: "dismissed", Reason: "accepted risk", ModifiedBy: "bob@example.com", ModifiedOn: 2},
+		},
+	}
+	p := policy.Policy{PolicyId: "pol-1", Name: "Open bucket", Severity: "high"}
+
+	conf := notification_template.TemplateConfigStruct{
+		BasicConfig: []notification_template.Config{
+			{FieldName: "project", Value: "SEC"},
+			{FieldName: "summary", Value: "<$PolicyName> on <$resourcename>"},
+		},
+		Open: []notification_template.Config{
+			{FieldName: "description", DisplayName: "Description", Value: "<$AlertId> (<$AlertStatus>) at <$AlertTime> in <$Bogus>"},
+		},
+		Dismissed: []notification_template.Config{
+			{FieldName: "summary", Value: "Dismissed by <$DismissedBy>: <$DismissalNote>"},
+			{FieldName: "account", AliasField: "$AccountName"},
+			{FieldName: "region", AliasField: "Zone"},
+		},
+	}
+
+	got := renderNotificationTemplate(notification_template.Jira, conf, a, p)
+	want := []notificationPreview{
+		{
+			State:   "open",
+			Subject: "Open bucket on web",
+			Body:    "P-1 (open) at 2023-11-14T22:13:20Z in <$Bogus>",
+			Fields: []notificationPreviewField{
+				{FieldName: "project", Value: "SEC"},
+				{FieldName: "summary", Value: "Open bucket on web"},
+				{FieldName: "description", DisplayName: "Description", Value: "P-1 (open) at 2023-11-14T22:13:20Z in <$Bogus>"},
+			},
+		},
+		{
+			State:   "resolved",
+			Subject: "Open bucket on web",
+			Fields: []notificationPreviewField{
+				{FieldName: "project", Value: "SEC"},
+				{FieldName: "summary", Value: "Open bucket on web"},
+			},
+		},
+		{
+			State:   "dismissed",
+			Subject: "Dismissed by bob@example.com: accepted risk",
+			Fields: []notificationPreviewField{
+				{FieldName: "project", Value: "SEC"},
+				{FieldName: "summary", Value: "Dismissed by bob@example.com: accepted risk"},
+				{FieldName: "account", Value: "prod"},
+				{FieldName: "region"},
+			},
+		},
+		{
+			State:   "snoozed",
+			Subject: "Open bucket on web",
+			Fields: []notificationPreviewField{
+				{FieldName: "project", Value: "SEC"},
+				{FieldName: "summary", Value: "Open bucket on web"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nexpected:\n%#v", got, want)
+	}
+
+	unknown := notificationTemplateUnknownAliases(conf)
+	wantUnknown := []notificationUnknownAlias{
+		{Alias: "Bogus", Fields: []string{"open.description"}},
+		{Alias: "Zone", Fields: []string{"dismissed.region"}},
+	}
+	if !reflect.DeepEqual(unknown, wantUnknown) {
+		t.Errorf("Got unknown aliases %#v, expected %#v", unknown, wantUnknown)
+	}
+}
+
+func TestRenderNotificationTemplateSubjectBody(t *testing.T) {
+	conf := notification_template.TemplateConfigStruct{
+		BasicConfig: []notification_template.Config{
+			{FieldName: "subject", Value: "Alert <$AlertId>"},
+			{FieldName: "custom_note", Value: "Owner: team"},
+			{FieldName: "short_description", Value: "short"},
+			{FieldName: "description", Value: "long"},
+		},
+	}
+	a := alert.Alert{Id: "P-2"}
+
+	cases := []struct {
+		integrationType string
+		subject         string
+		body            string
+	}{
+		{notification_template.Email, "Alert P-2", "Owner: team"},
+		{notification_template.ServiceNow, "short", "long"},
+		{"other", "", ""},
+	}
+
+	for _, tc := range cases {
+		got := renderNotificationTemplate(tc.integrationType, conf, a, policy.Policy{})
+		for _, preview := range got {
+			if preview.Subject != tc.subject || preview.Body != tc.body {
+				t.Errorf("%s %s: got %q / %q, expected %q / %q", tc.integrationType, preview.State, preview.Subject, preview.Body, tc.subject, tc.body)
+			}
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package prismacloud
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+/*
+Result sets that are too large for state (or for the 4MB gRPC message limit)
+can be written to a local file instead.  When output_file is set, each page
+of results is written to disk as it is read, so only a page is held in memory,
+and only the row count and a hash of the file are saved.
+*/
+
+const (
+	outputFormatJsonl = "jsonl"
+	outputFormatCsv   = "csv"
+)
+
+// addOutputFileSchema adds the output file params to a schema.
+func addOutputFileSchema(s map[string]*schema.Schema) {
+	s["output_file"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Write the results to this file instead of saving them in state",
+	}
+	s["output_format"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Default:     outputFormatJsonl,
+		Description: "Format of output_file",
+		ValidateFunc: validation.StringInSlice(
+			[]string{
+				outputFormatJsonl,
+				outputFormatCsv,
+			},
+			false,
+		),
+	}
+	s["output_count"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Computed:    true,
+		Description: "Number of results written to output_file",
+	}
+	s["output_sha256"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "SHA256 of output_file",
+	}
+}
+
+// resultFile writes results to a temp file, which replaces the output file
+// once all the results are written.
+type resultFile struct {
+	path    string
+	format  string
+	columns []string
+
+	f     *os.File
+	h     hash.Hash
+	w     *bufio.Writer
+	cw    *csv.Writer
+	count int
+}
+
+// createResultFile starts writing results of sample's type to path.
+func createResultFile(path, format string, sample interface{}) (*resultFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, "."+base+".*")
+	if err != nil {
+		return nil, err
+	}
+
+	ans := &resultFile{
+		path:   path,
+		format: format,
+		f:      f,
+		h:      sha256.New(),
+	}
+	ans.w = bufio.NewWriter(io.MultiWriter(f, ans.h))
+
+	if format == outputFormatCsv {
+		ans.columns = resultColumns(sample)
+		ans.cw = csv.NewWriter(ans.w)
+		if err = ans.cw.Write(ans.columns); err != nil {
+			ans.Abort()
+			return nil, err
+		}
+	}
+
+	return ans, nil
+}
+
+// resultColumns returns the CSV columns for v: the JSON names of a struct's
+// fields, in order, or the sorted keys of a map.
+func resultColumns(v interface{}) []string {
+	if m, ok := v.(map[string]interface{}); ok {
+		ans := make([]string, 0, len(m))
+		for key := range m {
+			ans = append(ans, key)
+		}
+		sort.Strings(ans)
+		return ans
+	}
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return []string{"value"}
+	}
+
+	ans := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		ans = append(ans, name)
+	}
+
+	return ans
+}
+
+// Write writes a single result.
+func (r *resultFile) Write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.count++
+
+	if r.format == outputFormatJsonl {
+		if _, err = r.w.Write(b); err != nil {
+			return err
+		}
+		return r.w.WriteByte('\n')
+	}
+
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(&m); err != nil {
+		m = map[string]interface{}{"value": json.RawMessage(b)}
+	}
+
+	row := make([]string, 0, len(r.columns))
+	for _, col := range r.columns {
+		row = append(row, csvCell(m[col]))
+	}
+
+	return r.cw.Write(row)
+}
+
+func csvCell(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return fmt.Sprintf("%t", x)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// WriteAll writes each result in a slice of results.
+func (r *resultFile) WriteAll(list interface{}) error {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("expected a list of results, got %T", list)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := r.Write(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close finishes writing, replacing the output file.
+func (r *resultFile) Close() error {
+	if r.cw != nil {
+		r.cw.Flush()
+		if err := r.cw.Error(); err != nil {
+			r.Abort()
+			return err
+		}
+	}
+
+	if err := r.w.Flush(); err != nil {
+		r.Abort()
+		return err
+	}
+
+	if err := r.f.Close(); err != nil {
+		os.Remove(r.f.Name())
+		return err
+	}
+
+	if err := os.Rename(r.f.Name(), r.path); err != nil {
+		os.Remove(r.f.Name())
+		return err
+	}
+
+	return nil
+}
+
+// Abort discards the results written so far, leaving the output file as is.
+func (r *resultFile) Abort() {
+	r.f.Close()
+	os.Remove(r.f.Name())
+}
+
+// Save saves the count and hash of a closed result file.
+func (r *resultFile) Save(d *schema.ResourceData) {
+	d.Set("output_count", r.count)
+	d.Set("output_sha256", hex.EncodeToString(r.h.Sum(nil)))
+}
+
+// clearOutputFile clears the output attributes when results go to state.
+func clearOutputFile(d *schema.ResourceData) {
+	d.Set("output_count", 0)
+	d.Set("output_sha256", "")
+}
@@ -0,0 +1,99 @@
+package prismacloud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testResult struct {
+	Id      string            `json:"id"`
+	Count   int               `json:"count"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Skipped string            `json:"-"`
+	private string
+}
+
+func TestResultFile(t *testing.T) {
+	list := []testResult{
+		{Id: "a", Count: 1},
+		{Id: "b,c", Count: 2, Tags: map[string]string{"k": "v"}},
+	}
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{outputFormatJsonl, "{\"id\":\"a\",\"count\":1}\n{\"id\":\"b,c\",\"count\":2,\"tags\":{\"k\":\"v\"}}\n"},
+		{outputFormatCsv, "id,count,tags\na,1,\n\"b,c\",2,\"{\"\"k\"\":\"\"v\"\"}\"\n"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "results."+tc.format)
+
+			out, err := createResultFile(path, tc.format, testResult{})
+			if err != nil {
+				t.Fatalf("Error creating result file: %s", err)
+			}
+			if err = out.WriteAll(list); err != nil {
+				t.Fatalf("Error writing results: %s", err)
+			}
+			if err = out.Close(); err != nil {
+				t.Fatalf("Error closing result file: %s", err)
+			}
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Error reading result file: %s", err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("Got %q, expected %q", string(b), tc.want)
+			}
+
+			if out.count != len(list) {
+				t.Errorf("Count is %d, expected %d", out.count, len(list))
+			}
+			sum := sha256.Sum256(b)
+			if got := hex.EncodeToString(out.h.Sum(nil)); got != hex.EncodeToString(sum[:]) {
+				t.Errorf("Hash is %s, expected %s", got, hex.EncodeToString(sum[:]))
+			}
+
+			entries, _ := os.ReadDir(filepath.Dir(path))
+			if len(entries) != 1 {
+				t.Errorf("Temp file left behind: %d files in output dir", len(entries))
+			}
+		})
+	}
+}
+
+func TestResultFileAbort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("Error writing old file: %s", err)
+	}
+
+	out, err := createResultFile(path, outputFormatJsonl, nil)
+	if err != nil {
+		t.Fatalf("Error creating result file: %s", err)
+	}
+	if err = out.Write(map[string]interface{}{"id": "a"}); err != nil {
+		t.Fatalf("Error writing result: %s", err)
+	}
+	out.Abort()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading result file: %s", err)
+	}
+	if string(b) != "old\n" {
+		t.Errorf("Aborting changed the output file to %q", string(b))
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Temp file left behind: %d files in output dir", len(entries))
+	}
+}
@@ -2,8 +2,10 @@ package prismacloud
 
 import (
 	"errors"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"golang.org/x/net/context"
 	"log"
 	"math/rand"
 	"strings"
@@ -23,6 +25,23 @@ func PollApiUntilSuccess(p Poller) {
 	}
 }
 
+// PollApiUntilSuccessContext is PollApiUntilSuccess, but gives up with the
+// last error once ctx is done.
+func PollApiUntilSuccessContext(ctx context.Context, p Poller) error {
+	for {
+		err := p()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s, last error: %s", ctx.Err(), err)
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
 func PollApiUntilSuccessCustom(p Poller) diag.Diagnostics {
 	for {
 		if err := p(); err == nil {
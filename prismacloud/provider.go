@@ -113,7 +113,10 @@ func Provider() *schema.Provider {
 		DataSourcesMap: map[string]*schema.Resource{
 			"prismacloud_account_group":                            dataSourceAccountGroup(),
 			"prismacloud_account_groups":                           dataSourceAccountGroups(),
+			"prismacloud_alert":                                    dataSourceAlert(),
 			"prismacloud_alert_rule":                               dataSourceAlertRule(),
+			"prismacloud_alert_rule_coverage":                      dataSourceAlertRuleCoverage(),
+			"prismacloud_alert_rule_scope":                         dataSourceAlertRuleScope(),
 			"prismacloud_alert_rules":                              dataSourceAlertRules(),
 			"prismacloud_alerts":                                   dataSourceAlerts(),
 			"prismacloud_anomaly_setting":                          dataSourceAnomalySetting(),
@@ -147,12 +150,14 @@ func Provider() *schema.Provider {
 			"prismacloud_permission_groups":                        dataSourcePermissionGroups(),
 			"prismacloud_policies":                                 dataSourcePolicies(),
 			"prismacloud_policy":                                   dataSourcePolicy(),
+			"prismacloud_policy_remediation_preview":               dataSourcePolicyRemediationPreview(),
 			"prismacloud_report":                                   dataSourceReport(),
 			"prismacloud_reports":                                  dataSourceReports(),
 			"prismacloud_resource_list":                            dataSourceResourceList(),
 			"prismacloud_resource_lists":                           dataSourceResourceLists(),
 			"prismacloud_rql_historic_search":                      dataSourceRqlHistoricSearch(),
 			"prismacloud_rql_historic_searches":                    dataSourceRqlHistoricSearches(),
+			"prismacloud_rql_query":                                dataSourceRqlQuery(),
 			"prismacloud_org_cloud_account_v2":                     dataSourceOrgV2CloudAccount(),
 			"prismacloud_org_cloud_account":                        dataSourceOrgCloudAccount(),
 			"prismacloud_org_cloud_accounts":                       dataSourceOrgCloudAccounts(),
@@ -161,6 +166,7 @@ func Provider() *schema.Provider {
 			"prismacloud_user_profile":                             dataSourceUserProfile(),
 			"prismacloud_user_profiles":                            dataSourceUserProfiles(),
 			"prismacloud_notification_template":                    dataSourceNotificationTemplate(),
+			"prismacloud_notification_template_preview":            dataSourceNotificationTemplatePreview(),
 			"prismacloud_notification_templates":                   dataSourceNotificationTemplates(),
 			"prismacloud_trusted_alert_ip":                         dataSourceTrustedAlertIp(),
 			"prismacloud_trusted_alert_ips":                        dataSourceTrustedAlertIps(),
@@ -171,7 +177,10 @@ func Provider() *schema.Provider {
 
 		ResourcesMap: map[string]*schema.Resource{
 			"prismacloud_account_group":                           resourceAccountGroup(),
+			"prismacloud_account_group_membership":                resourceAccountGroupMembership(),
+			"prismacloud_alert_dismissal":                         resourceAlertDismissal(),
 			"prismacloud_alert_rule":                              resourceAlertRule(),
+			"prismacloud_alert_rule_policy_attachment":            resourceAlertRulePolicyAttachment(),
 			"prismacloud_anomaly_settings":                        resourceAnomalySettings(),
 			"prismacloud_anomaly_trusted_list":                    resourceAnomalyTrustedList(),
 			"prismacloud_cloud_account":                           resourceCloudAccount(),
@@ -186,6 +195,8 @@ func Provider() *schema.Provider {
 			"prismacloud_integration":                             resourceIntegration(),
 			"prismacloud_permission_group":                        resourcePermissionGroup(),
 			"prismacloud_policy":                                  resourcePolicy(),
+			"prismacloud_policy_compliance_mapping":               resourcePolicyComplianceMapping(),
+			"prismacloud_policy_status":                           resourcePolicyStatus(),
 			"prismacloud_report":                                  resourceReport(),
 			"prismacloud_resource_list":                           resourceResourceList(),
 			"prismacloud_rql_search":                              resourceRqlSearch(),
@@ -196,8 +207,8 @@ func Provider() *schema.Provider {
 			"prismacloud_org_cloud_account_v2":                    resourceOrgV2CloudAccount(),
 			"prismacloud_notification_template":                   resourceNotificationTemplate(),
 			"prismacloud_trusted_alert_ip":                        resourceTrustedAlertIp(),
+			"prismacloud_trusted_alert_ip_cidr":                   resourceTrustedAlertIpCidr(),
 			"prismacloud_trusted_login_ip":                        resourceTrustedLoginIp(),
-			"prismacloud_trusted_login_ip_status":                 resourceLoginIpStatus(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -0,0 +1,164 @@
+package prismacloud
+
+import (
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-mux/tf5to6server"
+	"github.com/hashicorp/terraform-plugin-mux/tf6muxserver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var _ provider.Provider = &frameworkProvider{}
+
+/*
+frameworkProvider is the terraform-plugin-framework half of the provider.
+
+Resources and data sources that need plugin framework features (nested
+attributes, write-only attributes, plan modifiers, ...) are registered here
+instead of in Provider().  Its provider schema must stay identical to the one
+in Provider(), otherwise the mux server refuses to start.
+*/
+type frameworkProvider struct {
+	sdk *schema.Provider
+}
+
+// NewFrameworkProvider returns the plugin framework provider, which shares the
+// configured client of sdk.
+func NewFrameworkProvider(sdk *schema.Provider) provider.Provider {
+	return &frameworkProvider{sdk: sdk}
+}
+
+// ProviderServerFactory returns a protocol v6 server that muxes the SDKv2
+// provider with the plugin framework provider.
+func ProviderServerFactory(ctx context.Context) (func() tfprotov6.ProviderServer, error) {
+	return newProviderServerFactory(ctx, Provider())
+}
+
+func newProviderServerFactory(ctx context.Context, sdk *schema.Provider) (func() tfprotov6.ProviderServer, error) {
+	upgraded, err := tf5to6server.UpgradeServer(ctx, sdk.GRPCProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := []func() tfprotov6.ProviderServer{
+		func() tfprotov6.ProviderServer {
+			return upgraded
+		},
+		providerserver.NewProtocol6(NewFrameworkProvider(sdk)),
+	}
+
+	mux, err := tf6muxserver.NewMuxServer(ctx, servers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mux.ProviderServer, nil
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "prismacloud"
+}
+
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	// Validation is left to the SDKv2 provider, which is given the same config.
+	resp.Schema = providerschema.Schema{
+		Attributes: map[string]providerschema.Attribute{
+			"url": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "The API URL without the leading protocol",
+			},
+			"username": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "Access key ID",
+			},
+			"password": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "Secret key",
+				Sensitive:   true,
+			},
+			"customer_name": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "Customer name",
+			},
+			"protocol": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "The protocol (https or http)",
+			},
+			"port": providerschema.Int64Attribute{
+				Optional:    true,
+				Description: "If the port is non-standard for the protocol, the port number to use",
+			},
+			"timeout": providerschema.Int64Attribute{
+				Optional:    true,
+				Description: "The timeout in seconds for all communications with Prisma Cloud",
+			},
+			"skip_ssl_cert_verification": providerschema.BoolAttribute{
+				Optional:    true,
+				Description: "Skip SSL certificate verification",
+			},
+			"logging": providerschema.MapAttribute{
+				ElementType: types.BoolType,
+				Optional:    true,
+				Description: "Logging options for the API connection",
+			},
+			"disable_reconnect": providerschema.BoolAttribute{
+				Optional:    true,
+				Description: "Disable reconnecting on JWT expiration",
+			},
+			"json_web_token": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "JSON web token to use",
+				Sensitive:   true,
+			},
+			"json_config_file": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "Retrieve the provider configuration from this JSON file",
+			},
+			"max_retries": providerschema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of retries to be performed in case of rate limit",
+			},
+			"retry_max_delay": providerschema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum delay for exponential backoff strategy",
+			},
+			"retry_type": providerschema.StringAttribute{
+				Optional:    true,
+				Description: "Retry type",
+			},
+		},
+	}
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	// The mux server configures the SDKv2 provider first with the same
+	// config, so share its client (and its session) instead of logging in
+	// twice.
+	client, ok := p.sdk.Meta().(*pc.Client)
+	if !ok || client == nil {
+		resp.Diagnostics.AddError("Error configuring the Prisma Cloud client", "The SDKv2 provider has not been configured")
+		return
+	}
+
+	resp.ResourceData = client
+	resp.DataSourceData = client
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newLoginIpStatusResource,
+	}
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return nil
+}
@@ -2,18 +2,24 @@ package prismacloud
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"testing"
 
+	"golang.org/x/net/context"
+
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account"
 	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account/org"
 	"github.com/paloaltonetworks/prisma-cloud-go/settings/enterprise"
 
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 const (
@@ -21,7 +27,7 @@ const (
 )
 
 var (
-	testAccProviders                   map[string]*schema.Provider
+	testAccProtoV6ProviderFactories    map[string]func() (tfprotov6.ProviderServer, error)
 	testAccProvider                    *schema.Provider
 	originalEnterpriseSettings         *enterprise.Config
 	cloudAccounts                      map[string][]string
@@ -32,8 +38,14 @@ func init() {
 	var err error
 
 	testAccProvider = Provider()
-	testAccProviders = map[string]*schema.Provider{
-		"prismacloud": testAccProvider,
+	testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+		"prismacloud": func() (tfprotov6.ProviderServer, error) {
+			factory, err := newProviderServerFactory(context.Background(), testAccProvider)
+			if err != nil {
+				return nil, err
+			}
+			return factory(), nil
+		},
 	}
 
 	client := &pc.Client{}
@@ -44,6 +56,35 @@ func init() {
 	}
 }
 
+// testResourceDataWithConfig is schema.TestResourceDataRaw, but GetRawConfig
+// also returns the config.
+func testResourceDataWithConfig(t *testing.T, s map[string]*schema.Schema, raw map[string]interface{}) *schema.ResourceData {
+	t.Helper()
+	sm := schema.InternalMap(s)
+
+	diff, err := sm.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(raw), nil, nil, true)
+	if err != nil {
+		t.Fatalf("Error in diff: %s", err)
+	}
+	if diff == nil {
+		diff = &terraform.InstanceDiff{}
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("Error marshaling the config: %s", err)
+	}
+	if diff.RawConfig, err = ctyjson.Unmarshal(b, sm.CoreConfigSchema().ImpliedType()); err != nil {
+		t.Fatalf("Error in raw config: %s", err)
+	}
+
+	d, err := sm.Data(nil, diff)
+	if err != nil {
+		t.Fatalf("Error in data: %s", err)
+	}
+	return d
+}
+
 func cloudAccountFromEnv(style, label, name string, groups []string) (string, error) {
 	ctDesc := map[string]string{
 		account.TypeAws:     "AWS",
@@ -356,6 +397,30 @@ func TestProvider_impl(t *testing.T) {
 	var _ *schema.Provider = Provider()
 }
 
+func TestProviderServerFactory(t *testing.T) {
+	factory, err := ProviderServerFactory(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := factory().GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: %s", d.Summary, d.Detail)
+		}
+	}
+
+	if _, ok := resp.ResourceSchemas["prismacloud_trusted_login_ip_status"]; !ok {
+		t.Fatalf("prismacloud_trusted_login_ip_status is not served by the mux server")
+	}
+	if _, ok := resp.ResourceSchemas["prismacloud_policy"]; !ok {
+		t.Fatalf("prismacloud_policy is not served by the mux server")
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	if os.Getenv(PrismacloudJsonConfigFileEnvVar) == "" {
 		t.Fatalf("%s must be set for acceptance tests", PrismacloudJsonConfigFileEnvVar)
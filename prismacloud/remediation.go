@@ -0,0 +1,197 @@
+package prismacloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+A policy's CLI remediation is a command template with "${name}" placeholders.
+Prisma Cloud fills in the variables of the alerted resource (region,
+resourceName, and so on); any other placeholder must be a property of the
+template's JSON schema.
+*/
+
+var cliScriptPlaceholder = regexp.MustCompile(`\$\{([^{}]*)\}`)
+
+// cliScriptVariables maps the (lower cased) variables that Prisma Cloud
+// provides to where they are found in a resource's JSON.
+var cliScriptVariables = map[string][]string{
+	"account":       {"accountId", "account"},
+	"azurescope":    {"azureScope"},
+	"gcpzoneid":     {"gcpZoneId", "zone"},
+	"region":        {"regionId", "region"},
+	"resourcegroup": {"resourceGroup", "resourceGroupName"},
+	"resourceid":    {"id", "resourceId", "rrn"},
+	"resourcename":  {"name", "resourceName"},
+}
+
+// cliScriptPlaceholders returns the distinct placeholders of a template, in
+// order of appearance.
+func cliScriptPlaceholders(template string) []string {
+	var ans []string
+	for _, m := range cliScriptPlaceholder.FindAllStringSubmatch(template, -1) {
+		if !stringInSlice(m[1], ans) {
+			ans = append(ans, m[1])
+		}
+	}
+	return ans
+}
+
+// parseCliScriptJsonSchema parses a CLI script JSON schema, returning its
+// properties.
+func parseCliScriptJsonSchema(s string) (map[string]interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		return nil, fmt.Errorf("not valid JSON: %s", err)
+	}
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	if t, ok := obj["type"]; ok && t != "object" {
+		return nil, fmt.Errorf("\"type\" must be \"object\", got %v", t)
+	}
+
+	props := make(map[string]interface{})
+	if v, ok := obj["properties"]; ok {
+		if props, ok = v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("\"properties\" must be an object")
+		}
+		for name, p := range props {
+			if _, ok := p.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("property %q must be an object", name)
+			}
+		}
+	}
+
+	if v, ok := obj["required"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("\"required\" must be a list")
+		}
+		for _, r := range list {
+			name, ok := r.(string)
+			if !ok {
+				return nil, fmt.Errorf("\"required\" must be a list of strings")
+			}
+			if _, ok := props[name]; !ok {
+				return nil, fmt.Errorf("required property %q is not defined in \"properties\"", name)
+			}
+		}
+	}
+
+	return props, nil
+}
+
+// validateCliScriptJsonSchema is a ValidateFunc for a CLI script JSON schema.
+func validateCliScriptJsonSchema(v interface{}, k string) (ws []string, es []error) {
+	s, ok := v.(string)
+	if !ok || strings.TrimSpace(s) == "" {
+		return
+	}
+
+	if _, err := parseCliScriptJsonSchema(s); err != nil {
+		es = append(es, fmt.Errorf("%s: %s", k, err))
+	}
+
+	return
+}
+
+// checkCliScriptTemplate checks that every placeholder in the template is
+// either a Prisma Cloud variable or a property of the JSON schema.
+func checkCliScriptTemplate(template, jsonSchema string) error {
+	var props map[string]interface{}
+	if strings.TrimSpace(jsonSchema) != "" {
+		var err error
+		if props, err = parseCliScriptJsonSchema(jsonSchema); err != nil {
+			return err
+		}
+	}
+
+	var undefined []string
+	for _, name := range cliScriptPlaceholders(template) {
+		if name == "" {
+			return fmt.Errorf("empty placeholder \"${}\"")
+		}
+		if _, ok := cliScriptVariables[strings.ToLower(name)]; ok {
+			continue
+		}
+		if _, ok := props[name]; !ok {
+			undefined = append(undefined, name)
+		}
+	}
+
+	if len(undefined) != 0 {
+		return fmt.Errorf("placeholders not defined in the JSON schema: %s", strings.Join(undefined, ", "))
+	}
+
+	return nil
+}
+
+// renderCliScript fills in a template's placeholders.  Prisma Cloud variables
+// come from the resource JSON (or its "data"), and schema properties from
+// params, falling back to the property's default.
+func renderCliScript(template, jsonSchema string, resource map[string]interface{}, params map[string]string) (string, error) {
+	if err := checkCliScriptTemplate(template, jsonSchema); err != nil {
+		return "", err
+	}
+
+	var props map[string]interface{}
+	if strings.TrimSpace(jsonSchema) != "" {
+		props, _ = parseCliScriptJsonSchema(jsonSchema)
+	}
+
+	values := make(map[string]string)
+	var missing []string
+	for _, name := range cliScriptPlaceholders(template) {
+		var value string
+		var ok bool
+		if keys, isVar := cliScriptVariables[strings.ToLower(name)]; isVar {
+			value, ok = cliScriptVariableValue(resource, keys)
+		} else if value, ok = params[name]; !ok {
+			if p, _ := props[name].(map[string]interface{}); p != nil && p["default"] != nil {
+				value, ok = fmt.Sprint(p["default"]), true
+			}
+		}
+
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		values[name] = value
+	}
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("no value for placeholders: %s", strings.Join(missing, ", "))
+	}
+
+	return cliScriptPlaceholder.ReplaceAllStringFunc(template, func(m string) string {
+		return values[m[2:len(m)-1]]
+	}), nil
+}
+
+func cliScriptVariableValue(resource map[string]interface{}, keys []string) (string, bool) {
+	data, _ := resource["data"].(map[string]interface{})
+	for _, src := range []map[string]interface{}{resource, data} {
+		for _, key := range keys {
+			if v, ok := src[key]; ok && v != nil {
+				if s, ok := v.(string); ok {
+					return s, true
+				}
+				b, err := json.Marshal(v)
+				if err != nil {
+					return "", false
+				}
+				return string(b), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,135 @@
+package prismacloud
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const testCliScriptJsonSchema = `{
+  "type": "object",
+  "properties": {
+    "acl": {"type": "string", "default": "private"},
+    "days": {"type": "integer"}
+  },
+  "required": ["acl"]
+}`
+
+func TestCliScriptPlaceholders(t *testing.T) {
+	got := cliScriptPlaceholders("aws --region ${region} s3 ${acl} ${region} ${} $plain")
+	want := []string{"region", "acl", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Got %q, expected %q", got, want)
+	}
+}
+
+func TestValidateCliScriptJsonSchema(t *testing.T) {
+	cases := []struct {
+		schema string
+		want   string
+	}{
+		{testCliScriptJsonSchema, ""},
+		{"", ""},
+		{`{}`, ""},
+		{`{"type": "object"`, "not valid JSON"},
+		{`[]`, "expected a JSON object"},
+		{`{"type": "string"}`, `"type" must be "object"`},
+		{`{"properties": []}`, `"properties" must be an object`},
+		{`{"properties": {"a": 1}}`, `property "a" must be an object`},
+		{`{"properties": {"a": {}}, "required": "a"}`, `"required" must be a list`},
+		{`{"properties": {"a": {}}, "required": ["b"]}`, `required property "b"`},
+	}
+
+	for _, tc := range cases {
+		_, es := validateCliScriptJsonSchema(tc.schema, "cli_script_json_schema_string")
+		switch {
+		case tc.want == "" && len(es) != 0:
+			t.Errorf("%q: unexpected errors: %v", tc.schema, es)
+		case tc.want != "" && len(es) != 1:
+			t.Errorf("%q: expected one error, got %v", tc.schema, es)
+		case tc.want != "" && !strings.Contains(es[0].Error(), tc.want):
+			t.Errorf("%q: got %q, expected it to contain %q", tc.schema, es[0], tc.want)
+		}
+	}
+}
+
+func TestCheckCliScriptTemplate(t *testing.T) {
+	cases := []struct {
+		template string
+		schema   string
+		want     string
+	}{
+		{"aws --region ${region} s3api put-bucket-acl --bucket ${resourceName} --acl ${acl}", testCliScriptJsonSchema, ""},
+		{"az group update -g ${resourceGroup} --subscription ${ACCOUNT}", "", ""},
+		{"aws s3 ${acl} ${mode} ${days} ${other}", testCliScriptJsonSchema, "mode, other"},
+		{"aws s3 ${acl}", "", "acl"},
+		{"aws s3 ${}", "", "empty placeholder"},
+		{"aws s3 ${acl}", "[]", "expected a JSON object"},
+	}
+
+	for _, tc := range cases {
+		err := checkCliScriptTemplate(tc.template, tc.schema)
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%q: unexpected error: %s", tc.template, err)
+		case tc.want != "" && err == nil:
+			t.Errorf("%q: expected an error", tc.template)
+		case tc.want != "" && !strings.Contains(err.Error(), tc.want):
+			t.Errorf("%q: got %q, expected it to contain %q", tc.template, err, tc.want)
+		}
+	}
+}
+
+func TestRenderCliScript(t *testing.T) {
+	resource := map[string]interface{}{
+		"id":       "arn:aws:s3:::my-bucket",
+		"name":     "my-bucket",
+		"regionId": "us-east-1",
+		"data": map[string]interface{}{
+			"accountId": 123456789012,
+		},
+	}
+
+	got, err := renderCliScript(
+		"aws --region ${region} s3api put-bucket-acl --bucket ${resourceName} --acl ${acl} --account ${account} --days ${days}",
+		testCliScriptJsonSchema,
+		resource,
+		map[string]string{"days": "30"},
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "aws --region us-east-1 s3api put-bucket-acl --bucket my-bucket --acl private --account 123456789012 --days 30"
+	if got != want {
+		t.Errorf("Got %q, expected %q", got, want)
+	}
+
+	if _, err = renderCliScript("${resourceGroup} ${days}", testCliScriptJsonSchema, resource, nil); err == nil {
+		t.Errorf("Expected an error for missing values")
+	} else if !strings.Contains(err.Error(), "days, resourceGroup") {
+		t.Errorf("Got %q, expected it to name the missing placeholders", err)
+	}
+}
+
+func TestDataSourcePolicyRemediationPreviewRead(t *testing.T) {
+	r := dataSourcePolicyRemediationPreview()
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"cli_script_template":           "gcloud compute instances stop ${resourceName} --zone ${gcpZoneId} ${extra}",
+		"cli_script_json_schema_string": `{"properties": {"extra": {"default": "--quiet"}}}`,
+		"resource_json":                 `{"name": "vm-1", "data": {"zone": "us-central1-a"}}`,
+	})
+
+	if diags := r.ReadContext(context.Background(), d, nil); diags.HasError() {
+		t.Fatalf("Unexpected diagnostics: %v", diags)
+	}
+
+	if got, want := d.Get("command").(string), "gcloud compute instances stop vm-1 --zone us-central1-a --quiet"; got != want {
+		t.Errorf("Got %q, expected %q", got, want)
+	}
+	if got := d.Get("placeholders.#").(int); got != 3 {
+		t.Errorf("Got %d placeholders, expected 3", got)
+	}
+}
@@ -173,18 +173,21 @@ func createAccountGroup(ctx context.Context, d *schema.ResourceData, meta interf
 	client := meta.(*pc.Client)
 	obj := parseAccountGroup(d, "")
 
-	if err := group.Create(client, obj); err != nil {
+	created, err := createAccountGroupObject(client, obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := group.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := group.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := group.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = group.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
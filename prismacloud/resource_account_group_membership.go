@@ -0,0 +1,141 @@
+package prismacloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account/group"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceAccountGroupMembership() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createAccountGroupMembership,
+		ReadContext:   readAccountGroupMembership,
+		DeleteContext: deleteAccountGroupMembership,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importAccountGroupMembership,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"group_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Account group ID",
+			},
+			"account_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Cloud account ID",
+			},
+		},
+	}
+}
+
+func importAccountGroupMembership(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if len(strings.Split(d.Id(), IdSeparator)) != 2 {
+		return nil, fmt.Errorf("Expected an ID of the form <group_id>%s<account_id>, got %q", IdSeparator, d.Id())
+	}
+
+	groupId, accountId := IdToTwoStrings(d.Id())
+	d.Set("group_id", groupId)
+	d.Set("account_id", accountId)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// modifyAccountGroupMembership adds or removes a single account from the
+// group's live account list.  Memberships of the same group are serialized
+// so that parallel applies don't overwrite each other's changes.
+func modifyAccountGroupMembership(client *pc.Client, groupId, accountId string, add bool) error {
+	objectLocks.Lock("account_group/" + groupId)
+	defer objectLocks.Unlock("account_group/" + groupId)
+
+	obj, err := group.Get(client, groupId)
+	if err != nil {
+		return err
+	}
+
+	// The group is only written if the membership changes.
+	ids := make([]string, 0, len(obj.AccountIds)+1)
+	for _, id := range obj.AccountIds {
+		if id != accountId {
+			ids = append(ids, id)
+		}
+	}
+	member := len(ids) != len(obj.AccountIds)
+	if add == member {
+		return nil
+	}
+	if add {
+		ids = append(ids, accountId)
+	}
+	obj.AccountIds = ids
+
+	return group.Update(client, obj)
+}
+
+func createAccountGroupMembership(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	groupId := d.Get("group_id").(string)
+	accountId := d.Get("account_id").(string)
+
+	if diags := RetryWithBackoff(client, func() error {
+		return modifyAccountGroupMembership(client, groupId, accountId, true)
+	}); diags != nil {
+		return diags
+	}
+
+	d.SetId(TwoStringsToId(groupId, accountId))
+	return readAccountGroupMembership(ctx, d, meta)
+}
+
+func readAccountGroupMembership(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	groupId, accountId := IdToTwoStrings(d.Id())
+
+	obj, err := group.Get(client, groupId)
+	if err != nil {
+		if err == pc.AccountGroupNotFoundError || err == pc.ObjectNotFoundError {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	if !stringInSlice(accountId, obj.AccountIds) {
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("group_id", groupId)
+	d.Set("account_id", accountId)
+
+	return nil
+}
+
+func deleteAccountGroupMembership(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	groupId, accountId := IdToTwoStrings(d.Id())
+
+	if diags := RetryWithBackoff(client, func() error {
+		err := modifyAccountGroupMembership(client, groupId, accountId, false)
+		if err == pc.AccountGroupNotFoundError || err == pc.ObjectNotFoundError {
+			return nil
+		}
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,101 @@
+package prismacloud
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account/group"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccAccountGroupMembership(t *testing.T) {
+	accountId := os.Getenv("PRISMACLOUD_ACCOUNT_GROUP_MEMBER_ID")
+	if accountId == "" {
+		t.Skip("PRISMACLOUD_ACCOUNT_GROUP_MEMBER_ID must be set to an onboarded cloud account ID")
+	}
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccAccountGroupMembershipDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAccountGroupMembershipConfig(name, accountId),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAccountGroupMembershipExists("prismacloud_account_group_membership.test"),
+				),
+			},
+			{
+				ResourceName:      "prismacloud_account_group_membership.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckAccountGroupMembershipExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Resource not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Object label ID is not set")
+		}
+
+		client := testAccProvider.Meta().(*pc.Client)
+		groupId, accountId := IdToTwoStrings(rs.Primary.ID)
+		lo, err := group.Get(client, groupId)
+		if err != nil {
+			return fmt.Errorf("Error in get: %s", err)
+		}
+
+		if !stringInSlice(accountId, lo.AccountIds) {
+			return fmt.Errorf("Account %q is not in group %q", accountId, groupId)
+		}
+
+		return nil
+	}
+}
+
+func testAccAccountGroupMembershipDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*pc.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "prismacloud_account_group_membership" {
+			continue
+		}
+
+		groupId, accountId := IdToTwoStrings(rs.Primary.ID)
+		if lo, err := group.Get(client, groupId); err == nil && stringInSlice(accountId, lo.AccountIds) {
+			return fmt.Errorf("Account %q is still in group %q", accountId, groupId)
+		}
+	}
+
+	return nil
+}
+
+func testAccAccountGroupMembershipConfig(name, accountId string) string {
+	return fmt.Sprintf(`
+resource "prismacloud_account_group" "test" {
+    name = %q
+    description = "membership test"
+    lifecycle {
+        ignore_changes = [account_ids]
+    }
+}
+
+resource "prismacloud_account_group_membership" "test" {
+    group_id = prismacloud_account_group.test.group_id
+    account_id = %q
+}
+`, name, accountId)
+}
@@ -17,9 +17,9 @@ func TestAccAccountGroup(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccAccountGroupDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccAccountGroupDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccAccountGroupConfig(name, "first desc"),
@@ -0,0 +1,470 @@
+package prismacloud
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// alertDismissalLimit is the most alerts a filter can select.
+const alertDismissalLimit = 100000
+
+func resourceAlertDismissal() *schema.Resource {
+	filterKeys := []string{
+		"filter.0.policy_ids",
+		"filter.0.account_ids",
+		"filter.0.resource_ids",
+		"filter.0.tags",
+	}
+
+	return &schema.Resource{
+		CreateContext: createAlertDismissal,
+		ReadContext:   readAlertDismissal,
+		UpdateContext: updateAlertDismissal,
+		DeleteContext: deleteAlertDismissal,
+
+		CustomizeDiff: customizeDiffAlertDismissal,
+
+		Schema: map[string]*schema.Schema{
+			"alert_ids": {
+				Type:         schema.TypeSet,
+				Optional:     true,
+				Description:  "IDs of the alerts to dismiss",
+				ExactlyOneOf: []string{"alert_ids", "filter"},
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"filter": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Select alerts matching all of these",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"policy_ids": {
+							Type:         schema.TypeSet,
+							Optional:     true,
+							Description:  "Alerts of any of these policies",
+							AtLeastOneOf: filterKeys,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"account_ids": {
+							Type:         schema.TypeSet,
+							Optional:     true,
+							Description:  "Alerts in any of these cloud accounts",
+							AtLeastOneOf: filterKeys,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"resource_ids": {
+							Type:         schema.TypeSet,
+							Optional:     true,
+							Description:  "Alerts of any of these resources",
+							AtLeastOneOf: filterKeys,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"tags": {
+							Type:         schema.TypeMap,
+							Optional:     true,
+							Description:  "Alerts of resources having all of these tags",
+							AtLeastOneOf: filterKeys,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"reason": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Dismissal note",
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"snooze_until": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Snooze the alerts until this time (RFC3339) instead of dismissing them",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+
+			// Output.
+			"alerts": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The managed alerts",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"alert_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Alert ID",
+						},
+						"status": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Alert status",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func loadAlertDismissalRecords(d interface{ Get(string) interface{} }) map[string]string {
+	return parseAlertDismissalRecords(d.Get("alerts").([]interface{}))
+}
+
+// priorAlertDismissalRecords returns the alerts managed before the apply.
+// customizeDiffAlertDismissal plans "alerts" as unknown whenever the managed
+// alerts change, which leaves nothing for d.Get during the apply.
+func priorAlertDismissalRecords(d *schema.ResourceData) map[string]string {
+	o, _ := d.GetChange("alerts")
+	return parseAlertDismissalRecords(o.([]interface{}))
+}
+
+func parseAlertDismissalRecords(list []interface{}) map[string]string {
+	ans := make(map[string]string, len(list))
+	for _, x := range list {
+		m := x.(map[string]interface{})
+		ans[m["alert_id"].(string)] = m["status"].(string)
+	}
+
+	return ans
+}
+
+func saveAlertDismissalRecords(d *schema.ResourceData, records map[string]string) {
+	ids := make([]string, 0, len(records))
+	for k := range records {
+		ids = append(ids, k)
+	}
+	sort.Strings(ids)
+
+	ans := make([]interface{}, 0, len(ids))
+	for _, k := range ids {
+		ans = append(ans, map[string]interface{}{
+			"alert_id": k,
+			"status":   records[k],
+		})
+	}
+
+	if err := d.Set("alerts", ans); err != nil {
+		log.Printf("[WARN] Error setting 'alerts' for %q: %s", d.Id(), err)
+	}
+}
+
+// snoozeUntil returns the end of the snooze, if any.
+func snoozeUntil(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, err == nil
+}
+
+// alertDismissalFilterRequest returns the alert listing request of a filter,
+// and the tags the alerts' resources must have.
+func alertDismissalFilterRequest(spec map[string]interface{}) (alert.Request, map[string]string) {
+	req := alert.Request{
+		TimeRange: allAlertsTimeRange(),
+	}
+
+	// Filters of the same name match any of the values.
+	params := []struct {
+		key  string
+		name string
+	}{
+		{"policy_ids", "policy.id"},
+		{"account_ids", "cloud.accountId"},
+		{"resource_ids", "resource.id"},
+	}
+	for _, p := range params {
+		values := SetToStringSlice(spec[p.key].(*schema.Set))
+		sort.Strings(values)
+		for _, v := range values {
+			req.Filters = append(req.Filters, alert.Filter{
+				Name:     p.name,
+				Operator: "=",
+				Value:    v,
+			})
+		}
+	}
+
+	tags := make(map[string]string)
+	for k, v := range spec["tags"].(map[string]interface{}) {
+		tags[k] = v.(string)
+	}
+	if len(tags) != 0 {
+		req.Detailed = true
+	}
+
+	return req, tags
+}
+
+// selectDismissalAlerts returns the IDs of the alerts to manage from the
+// alerts matching a filter: the open ones, plus the ones already managed
+// unless they have since been resolved.
+func selectDismissalAlerts(list []alert.Alert, managed map[string]string, tags map[string]string) []string {
+	ans := make([]string, 0, len(list))
+	for _, a := range list {
+		if !alertHasTags(a, tags) {
+			continue
+		}
+		_, ok := managed[a.Id]
+		if a.Status == alertStatusOpen || (ok && a.Status != alertStatusResolved) {
+			ans = append(ans, a.Id)
+		}
+	}
+	sort.Strings(ans)
+
+	return ans
+}
+
+// alertDismissalSelection returns the IDs of the selected alerts.
+func alertDismissalSelection(client *pc.Client, ids *schema.Set, filter []interface{}, managed map[string]string) ([]string, error) {
+	if ids != nil && ids.Len() != 0 {
+		ans := SetToStringSlice(ids)
+		sort.Strings(ans)
+		return ans, nil
+	}
+
+	if len(filter) == 0 || filter[0] == nil {
+		return nil, nil
+	}
+
+	req, tags := alertDismissalFilterRequest(filter[0].(map[string]interface{}))
+	var list []alert.Alert
+	if _, err := listAlerts(client, req, alertDismissalLimit, func(page []alert.Alert) error {
+		list = append(list, page...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return selectDismissalAlerts(list, managed, tags), nil
+}
+
+func customizeDiffAlertDismissal(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	// Once a snooze is over, the alerts reopen as expected.
+	if t, ok := snoozeUntil(d.Get("snooze_until").(string)); ok && time.Now().After(t) {
+		return nil
+	}
+
+	// Alerts reopened outside of Terraform are dismissed again.
+	records := loadAlertDismissalRecords(d)
+	for _, status := range records {
+		if status == alertStatusOpen {
+			return d.SetNewComputed("alerts")
+		}
+	}
+
+	// New alerts matching the filter are dismissed too.
+	if len(d.Get("filter").([]interface{})) == 0 || !d.NewValueKnown("filter") {
+		return nil
+	}
+
+	client, ok := meta.(*pc.Client)
+	if !ok {
+		return nil
+	}
+
+	ids, err := alertDismissalSelection(client, nil, d.Get("filter").([]interface{}), records)
+	if err != nil {
+		return err
+	}
+
+	current := make([]string, 0, len(records))
+	for k := range records {
+		current = append(current, k)
+	}
+	sort.Strings(current)
+
+	if fmt.Sprint(ids) != fmt.Sprint(current) {
+		return d.SetNewComputed("alerts")
+	}
+
+	return nil
+}
+
+// planAlertDismissal returns the alerts to dismiss or snooze, and the
+// previously managed alerts to reopen.
+func planAlertDismissal(client *pc.Client, d *schema.ResourceData) ([]string, []string, error) {
+	records := priorAlertDismissalRecords(d)
+	ids, err := alertDismissalSelection(client, d.Get("alert_ids").(*schema.Set), d.Get("filter").([]interface{}), records)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reopen []string
+	for k, status := range records {
+		if !stringInSlice(k, ids) && (status == alertStatusDismissed || status == alertStatusSnoozed) {
+			reopen = append(reopen, k)
+		}
+	}
+	sort.Strings(reopen)
+
+	return ids, reopen, nil
+}
+
+// applyAlertDismissal dismisses or snoozes the selected alerts, and reopens
+// the alerts that are no longer selected.
+func applyAlertDismissal(client *pc.Client, d *schema.ResourceData) diag.Diagnostics {
+	now := time.Now()
+	var until int
+	if t, ok := snoozeUntil(d.Get("snooze_until").(string)); ok {
+		if !t.After(now) {
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "Snooze is already over",
+				Detail:        fmt.Sprintf("The alerts cannot be snoozed until %s, which is in the past.", t.Format(time.RFC3339)),
+				AttributePath: cty.GetAttrPath("snooze_until"),
+			}}
+		}
+		until = int(t.UnixMilli())
+	}
+
+	ids, reopen, err := planAlertDismissal(client, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if len(reopen) != 0 {
+		if diags := RetryWithBackoff(client, func() error {
+			return reopenAlerts(client, reopen)
+		}); diags != nil {
+			return diags
+		}
+	}
+
+	if len(ids) != 0 {
+		if diags := RetryWithBackoff(client, func() error {
+			return dismissAlerts(client, ids, d.Get("reason").(string), int(now.UnixMilli()), until)
+		}); diags != nil {
+			return diags
+		}
+	}
+
+	// The statuses are filled in when read.
+	records := make(map[string]string, len(ids))
+	for _, k := range ids {
+		records[k] = ""
+	}
+	saveAlertDismissalRecords(d, records)
+
+	return nil
+}
+
+func createAlertDismissal(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+
+	if diags := applyAlertDismissal(client, d); diags != nil {
+		return diags
+	}
+
+	d.SetId(id.UniqueId())
+	return readAlertDismissal(ctx, d, meta)
+}
+
+func readAlertDismissal(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	records := loadAlertDismissalRecords(d)
+
+	ids := make([]string, 0, len(records))
+	for k := range records {
+		ids = append(ids, k)
+	}
+	sort.Strings(ids)
+
+	var statuses map[string]string
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		statuses, err = getAlertStatuses(client, ids)
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	// Alerts that no longer exist are dropped, and any reopened outside of
+	// Terraform show up as open.
+	for k := range records {
+		if status, ok := statuses[k]; ok {
+			records[k] = status
+		} else {
+			delete(records, k)
+		}
+	}
+	saveAlertDismissalRecords(d, records)
+
+	return nil
+}
+
+func updateAlertDismissal(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+
+	if diags := applyAlertDismissal(client, d); diags != nil {
+		return diags
+	}
+
+	return readAlertDismissal(ctx, d, meta)
+}
+
+func deleteAlertDismissal(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+
+	ids := make([]string, 0)
+	for k := range loadAlertDismissalRecords(d) {
+		ids = append(ids, k)
+	}
+	sort.Strings(ids)
+
+	var statuses map[string]string
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		statuses, err = getAlertStatuses(client, ids)
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	// Only alerts still dismissed or snoozed are reopened.
+	reopen := make([]string, 0, len(ids))
+	for _, k := range ids {
+		if s := statuses[k]; s == alertStatusDismissed || s == alertStatusSnoozed {
+			reopen = append(reopen, k)
+		}
+	}
+
+	if len(reopen) != 0 {
+		if diags := RetryWithBackoff(client, func() error {
+			return reopenAlerts(client, reopen)
+		}); diags != nil {
+			return diags
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,130 @@
+package prismacloud
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/paloaltonetworks/prisma-cloud-go/alert"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestSelectDismissalAlerts(t *testing.T) {
+	tagged := func(id, status string, tags interface{}) alert.Alert {
+		return alert.Alert{Id: id, Status: status, Resource: alert.Resource{Tags: tags}}
+	}
+
+	list := []alert.Alert{
+		tagged("P-1", alertStatusOpen, map[string]interface{}{"env": "dev", "team": "a"}),
+		tagged("P-2", alertStatusOpen, map[string]interface{}{"env": "prod"}),
+		tagged("P-3", alertStatusDismissed, map[string]interface{}{"env": "dev"}),
+		tagged("P-4", alertStatusResolved, map[string]interface{}{"env": "dev"}),
+		tagged("P-5", alertStatusSnoozed, []interface{}{
+			map[string]interface{}{"key": "env", "value": "dev"},
+		}),
+		tagged("P-6", alertStatusDismissed, nil),
+	}
+
+	cases := []struct {
+		name    string
+		managed map[string]string
+		tags    map[string]string
+		want    []string
+	}{
+		{"open", nil, nil, []string{"P-1", "P-2"}},
+		{"managed", map[string]string{"P-3": "", "P-4": "", "P-6": ""}, nil, []string{"P-1", "P-2", "P-3", "P-6"}},
+		{"tags", map[string]string{"P-5": "", "P-6": ""}, map[string]string{"env": "dev"}, []string{"P-1", "P-5"}},
+		{"all tags", nil, map[string]string{"env": "dev", "team": "b"}, []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := selectDismissalAlerts(list, tc.managed, tc.tags)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Got %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlanAlertDismissal(t *testing.T) {
+	// alert_ids is a set, so its elements are keyed by hash.
+	id := func(v string) string {
+		return fmt.Sprintf("alert_ids.%d", schema.HashString(v))
+	}
+
+	// P-1 and P-2 are dismissed, and P-3 was since resolved.
+	state := &terraform.InstanceState{
+		ID: "x",
+		Attributes: map[string]string{
+			"id":                "x",
+			"alert_ids.#":       "3",
+			id("P-1"):           "P-1",
+			id("P-2"):           "P-2",
+			id("P-3"):           "P-3",
+			"reason":            "accepted",
+			"alerts.#":          "3",
+			"alerts.0.alert_id": "P-1",
+			"alerts.0.status":   alertStatusDismissed,
+			"alerts.1.alert_id": "P-2",
+			"alerts.1.status":   alertStatusDismissed,
+			"alerts.2.alert_id": "P-3",
+			"alerts.2.status":   alertStatusResolved,
+		},
+	}
+
+	cases := []struct {
+		name   string
+		diff   map[string]*terraform.ResourceAttrDiff
+		ids    []string
+		reopen []string
+	}{
+		{
+			"reason",
+			map[string]*terraform.ResourceAttrDiff{
+				"reason":   {Old: "accepted", New: "false positive"},
+				"alerts.#": {Old: "3", NewComputed: true},
+			},
+			[]string{"P-1", "P-2", "P-3"},
+			nil,
+		},
+		{
+			// The resolved P-3 is left as is.
+			"alert ids",
+			map[string]*terraform.ResourceAttrDiff{
+				"alert_ids.#": {Old: "3", New: "2"},
+				id("P-1"):     {Old: "P-1", NewRemoved: true},
+				id("P-2"):     {Old: "P-2", New: "P-2"},
+				id("P-3"):     {Old: "P-3", NewRemoved: true},
+				id("P-4"):     {New: "P-4"},
+				"alerts.#":    {Old: "3", NewComputed: true},
+			},
+			[]string{"P-2", "P-4"},
+			[]string{"P-1"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := schema.InternalMap(resourceAlertDismissal().Schema).Data(state, &terraform.InstanceDiff{Attributes: tc.diff})
+			if err != nil {
+				t.Fatalf("Error building the resource data: %s", err)
+			}
+
+			// With alert_ids set, no alerts are listed, so no client is
+			// needed.
+			ids, reopen, err := planAlertDismissal(nil, d)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("Dismissing %v, expected %v", ids, tc.ids)
+			}
+			if !reflect.DeepEqual(reopen, tc.reopen) {
+				t.Errorf("Reopening %v, expected %v", reopen, tc.reopen)
+			}
+		})
+	}
+}
@@ -1,12 +1,19 @@
 package prismacloud
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"golang.org/x/net/context"
-	"log"
 
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -23,6 +30,8 @@ func resourceAlertRule() *schema.Resource {
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
+		CustomizeDiff: customizeDiffAlertRule,
+
 		Schema: map[string]*schema.Schema{
 			"policy_scan_config_id": {
 				Type:        schema.TypeString,
@@ -354,25 +363,31 @@ func resourceAlertRule() *schema.Resource {
 							Description: "Template ID",
 						},
 						"timezone_id": {
-							Type:        schema.TypeString,
-							Computed:    true,
-							Description: "Timezone ID",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Computed:     true,
+							Description:  "Timezone ID",
+							ValidateFunc: validateIanaTimezone,
 						},
 						"day_of_month": {
-							Type:        schema.TypeInt,
-							Computed:    true,
-							Description: "Day of month",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							Description:  "Day of month",
+							ValidateFunc: validation.IntBetween(1, 31),
 						},
 						"r_rule_schedule": {
-							Type:        schema.TypeString,
-							Optional:    true,
-							Description: "R rule schedule",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  "R rule schedule",
+							ValidateFunc: validateRrule,
 						},
 						"frequency_from_r_rule": {
 							Type:        schema.TypeString,
 							Computed:    true,
 							Description: "Frequency from R rule",
 						},
+						"next_runs": nextRunsSchema("notifications are sent"),
 						"hour_of_day": {
 							Type:        schema.TypeInt,
 							Computed:    true,
@@ -540,7 +555,9 @@ func parseAlertRule(d *schema.ResourceData, id string) rule.Rule {
 				IncludeRemediation: nc["include_remediation"].(bool),
 				Type:               nc["config_type"].(string),
 				TemplateId:         nc["template_id"].(string),
+				TimezoneId:         nc["timezone_id"].(string),
 				RruleSchedule:      nc["r_rule_schedule"].(string),
+				DayOfMonth:         nc["day_of_month"].(int),
 			})
 
 		}
@@ -649,7 +666,8 @@ func saveAlertRule(d *schema.ResourceData, o rule.Rule) {
 				"timezone_id":           nc.TimezoneId,
 				"day_of_month":          nc.DayOfMonth,
 				"r_rule_schedule":       nc.RruleSchedule,
-				"frequency_from_r_rule": nc.FrequencyFromRrule,
+				"frequency_from_r_rule": rruleFrequency(nc.RruleSchedule, nc.FrequencyFromRrule),
+				"next_runs":             rruleNextRunTimes(nc.RruleSchedule, nc.TimezoneId, nc.HourOfDay, time.Now()),
 				"hour_of_day":           nc.HourOfDay,
 				"days_of_week":          days,
 			})
@@ -661,23 +679,99 @@ func saveAlertRule(d *schema.ResourceData, o rule.Rule) {
 	}
 }
 
+// customizeDiffAlertRule flags notification schedules whose RRULE
+// contradicts the legacy frequency and day_of_month params.
+func customizeDiffAlertRule(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	ncl := d.GetRawConfig().GetAttr("notification_config")
+	if !ncl.IsKnown() || ncl.IsNull() {
+		return nil
+	}
+
+	// Without a client, such as in validation, only the values themselves
+	// are checked.
+	var lookup *notificationLookup
+	if client, ok := meta.(*pc.Client); ok {
+		lookup = newNotificationLookup(client)
+	}
+
+	var errs []error
+	i := 0
+	for it := ncl.ElementIterator(); it.Next(); i++ {
+		_, nc := it.Element()
+		path := fmt.Sprintf("notification_config.%d", i)
+		if err := checkNotificationSchedule(nc); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", path, err))
+		}
+		errs = append(errs, checkNotificationChannels(lookup, path, nc)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkNotificationSchedule checks the legacy schedule params of a
+// notification config against its RRULE.  Invalid RRULEs are left to
+// validateRrule.
+func checkNotificationSchedule(nc cty.Value) error {
+	if !nc.IsKnown() || nc.IsNull() {
+		return nil
+	}
+
+	v := nc.GetAttr("r_rule_schedule")
+	if !v.IsKnown() || v.IsNull() || v.AsString() == "" {
+		return nil
+	}
+	rr := v.AsString()
+
+	r, err := parseRrule(rr, rruleStart(time.Now(), "", 0))
+	if err != nil {
+		return nil
+	}
+
+	if v := nc.GetAttr("frequency"); v.IsKnown() && !v.IsNull() && v.AsString() != "" {
+		freq := v.AsString()
+		switch {
+		case r.Frequency() == "":
+			return fmt.Errorf("r_rule_schedule is FREQ=%s, which has no matching frequency; remove frequency", r.Freq)
+		case freq != r.Frequency():
+			return fmt.Errorf("frequency is %q, but r_rule_schedule is FREQ=%s (%q)", freq, r.Freq, r.Frequency())
+		}
+	}
+
+	if v := nc.GetAttr("day_of_month"); v.IsKnown() && !v.IsNull() {
+		dom, _ := v.AsBigFloat().Int64()
+		days := r.DaysOfMonth()
+		switch {
+		case r.Freq != rruleMonthly:
+			return fmt.Errorf("day_of_month is %d, but r_rule_schedule is FREQ=%s, not FREQ=MONTHLY", dom, r.Freq)
+		case days == nil:
+			return fmt.Errorf("day_of_month is %d, but r_rule_schedule picks days of the month by weekday", dom)
+		case len(days) != 1 || int64(days[0]) != dom:
+			return fmt.Errorf("day_of_month is %d, but r_rule_schedule fires on day %s of the month", dom, strings.Trim(strings.Join(strings.Fields(fmt.Sprint(days)), ", "), "[]"))
+		}
+	}
+
+	return nil
+}
+
 func createAlertRule(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	var err error
 	client := meta.(*pc.Client)
 	o := parseAlertRule(d, "")
 
-	if err = rule.Create(client, o); err != nil {
+	created, err := createAlertRuleObject(client, o)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := rule.Identify(client, o.Name)
-		return err
-	})
+	id := created.PolicyScanConfigId
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := rule.Identify(client, o.Name)
+			return err
+		})
 
-	id, err := rule.Identify(client, o.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = rule.Identify(client, o.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -703,7 +797,13 @@ func readAlertRule(ctx context.Context, d *schema.ResourceData, meta interface{}
 	}
 
 	saveAlertRule(d, o)
-	return nil
+
+	tenant := getTenantSettings(client)
+	var policies map[string]policy.Policy
+	if o.AllowAutoRemediate {
+		policies = tenant.Policies(client)
+	}
+	return alertRuleSettingsWarnings(o, tenant.Enterprise(client), policies)
 }
 
 func updateAlertRule(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
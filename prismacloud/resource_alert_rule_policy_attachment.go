@@ -0,0 +1,180 @@
+package prismacloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceAlertRulePolicyAttachment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createAlertRulePolicyAttachment,
+		ReadContext:   readAlertRulePolicyAttachment,
+		DeleteContext: deleteAlertRulePolicyAttachment,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importAlertRulePolicyAttachment,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"alert_rule_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Alert rule ID",
+			},
+			"policy_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Policy ID",
+			},
+			"exclude": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Add the policy to the alert rule's excluded policies instead",
+			},
+		},
+	}
+}
+
+func importAlertRulePolicyAttachment(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if len(strings.Split(d.Id(), IdSeparator)) != 2 {
+		return nil, fmt.Errorf("Expected an ID of the form <alert_rule_id>%s<policy_id>, got %q", IdSeparator, d.Id())
+	}
+
+	client := meta.(*pc.Client)
+	ruleId, policyId := IdToTwoStrings(d.Id())
+
+	obj, err := rule.Get(client, ruleId)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("alert_rule_id", ruleId)
+	d.Set("policy_id", policyId)
+	d.Set("exclude", stringInSlice(policyId, obj.ExcludedPolicies))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// modifyAlertRulePolicies adds or removes a single policy from the alert
+// rule's live policy list, or its excluded policy list.  Attachments to the
+// same alert rule are serialized so that parallel applies don't overwrite
+// each other's changes.
+func modifyAlertRulePolicies(client *pc.Client, ruleId, policyId string, exclude, add bool) error {
+	objectLocks.Lock("alert_rule/" + ruleId)
+	defer objectLocks.Unlock("alert_rule/" + ruleId)
+
+	obj, err := rule.Get(client, ruleId)
+	if err != nil {
+		return err
+	}
+
+	cur := obj.Policies
+	if exclude {
+		cur = obj.ExcludedPolicies
+	}
+
+	ids := make([]string, 0, len(cur)+1)
+	for _, id := range cur {
+		if id != policyId {
+			ids = append(ids, id)
+		}
+	}
+	if add {
+		ids = append(ids, policyId)
+	} else if len(ids) == len(cur) {
+		return nil
+	}
+
+	if exclude {
+		obj.ExcludedPolicies = ids
+	} else {
+		obj.Policies = ids
+	}
+
+	return rule.Update(client, obj)
+}
+
+func createAlertRulePolicyAttachment(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	ruleId := d.Get("alert_rule_id").(string)
+	policyId := d.Get("policy_id").(string)
+	exclude := d.Get("exclude").(bool)
+
+	if diags := RetryWithBackoff(client, func() error {
+		return modifyAlertRulePolicies(client, ruleId, policyId, exclude, true)
+	}); diags != nil {
+		return diags
+	}
+
+	d.SetId(TwoStringsToId(ruleId, policyId))
+	return readAlertRulePolicyAttachment(ctx, d, meta)
+}
+
+func readAlertRulePolicyAttachment(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	ruleId, policyId := IdToTwoStrings(d.Id())
+	exclude := d.Get("exclude").(bool)
+
+	obj, err := rule.Get(client, ruleId)
+	if err != nil {
+		if err == pc.ObjectNotFoundError {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	list := obj.Policies
+	if exclude {
+		list = obj.ExcludedPolicies
+	}
+	if obj.Deleted || !stringInSlice(policyId, list) {
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("alert_rule_id", ruleId)
+	d.Set("policy_id", policyId)
+	d.Set("exclude", exclude)
+
+	// Alert rules scanning all policies ignore their policy list.
+	if obj.ScanAll && !exclude {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Policy attachment has no effect",
+			Detail:   fmt.Sprintf("Alert rule %q scans all policies, so attaching policy %q does not change what it alerts on.  Set exclude to exclude the policy instead.", obj.Name, policyId),
+		}}
+	}
+
+	return nil
+}
+
+func deleteAlertRulePolicyAttachment(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	ruleId, policyId := IdToTwoStrings(d.Id())
+	exclude := d.Get("exclude").(bool)
+
+	if diags := RetryWithBackoff(client, func() error {
+		err := modifyAlertRulePolicies(client, ruleId, policyId, exclude, false)
+		if err == pc.ObjectNotFoundError {
+			return nil
+		}
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,118 @@
+package prismacloud
+
+import (
+	"fmt"
+	"testing"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccAlertRulePolicyAttachment(t *testing.T) {
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccAlertRulePolicyAttachmentDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAlertRulePolicyAttachmentConfig(name),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAlertRulePolicyAttachmentExists("prismacloud_alert_rule_policy_attachment.test"),
+					testAccCheckAlertRulePolicyAttachmentExists("prismacloud_alert_rule_policy_attachment.other"),
+				),
+			},
+			{
+				ResourceName:      "prismacloud_alert_rule_policy_attachment.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckAlertRulePolicyAttachmentExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Resource not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Object label ID is not set")
+		}
+
+		client := testAccProvider.Meta().(*pc.Client)
+		ruleId, policyId := IdToTwoStrings(rs.Primary.ID)
+		lo, err := rule.Get(client, ruleId)
+		if err != nil {
+			return fmt.Errorf("Error in get: %s", err)
+		}
+
+		if !stringInSlice(policyId, lo.Policies) {
+			return fmt.Errorf("Policy %q is not in alert rule %q", policyId, ruleId)
+		}
+
+		return nil
+	}
+}
+
+func testAccAlertRulePolicyAttachmentDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*pc.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "prismacloud_alert_rule_policy_attachment" {
+			continue
+		}
+
+		ruleId, policyId := IdToTwoStrings(rs.Primary.ID)
+		if lo, err := rule.Get(client, ruleId); err == nil && !lo.Deleted && stringInSlice(policyId, lo.Policies) {
+			return fmt.Errorf("Policy %q is still in alert rule %q", policyId, ruleId)
+		}
+	}
+
+	return nil
+}
+
+func testAccAlertRulePolicyAttachmentConfig(name string) string {
+	return fmt.Sprintf(`
+data "prismacloud_policies" "x" {
+    filters = {
+        "policy.severity" = "high",
+        "policy.enabled" = "true",
+    }
+}
+
+resource "prismacloud_account_group" "x" {
+    name = %q
+    description = "for alert rule policy attachment acctest"
+}
+
+resource "prismacloud_alert_rule" "x" {
+    name = %q
+    enabled = false
+    policies = [data.prismacloud_policies.x.listing.0.policy_id]
+    target {
+        account_groups = [prismacloud_account_group.x.group_id]
+    }
+    lifecycle {
+        ignore_changes = [policies]
+    }
+}
+
+resource "prismacloud_alert_rule_policy_attachment" "test" {
+    alert_rule_id = prismacloud_alert_rule.x.policy_scan_config_id
+    policy_id = data.prismacloud_policies.x.listing.1.policy_id
+}
+
+resource "prismacloud_alert_rule_policy_attachment" "other" {
+    alert_rule_id = prismacloud_alert_rule.x.policy_scan_config_id
+    policy_id = data.prismacloud_policies.x.listing.2.policy_id
+}
+`, name, name)
+}
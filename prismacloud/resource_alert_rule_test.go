@@ -29,9 +29,9 @@ func TestAccAlertRule(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccAlertRuleDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccAlertRuleDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccAlertRuleConfig(grp, name, "alert rule desc1"),
@@ -484,7 +484,7 @@ func saveCloudAccount(d *schema.ResourceData, dest string, obj interface{}) {
 
 func createCloudAccount(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
-	cloudType, name, obj := parseCloudAccount(d)
+	cloudType, _, obj := parseCloudAccount(d)
 
 	if err := account.Create(client, obj); err != nil {
 		if strings.Contains(err.Error(), "duplicate_cloud_account") {
@@ -496,15 +496,8 @@ func createCloudAccount(ctx context.Context, d *schema.ResourceData, meta interf
 		}
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := account.Identify(client, cloudType, name)
-		return err
-	})
-
-	id, err := account.Identify(client, cloudType, name)
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	// The cloud account ID is the one in the config.
+	id := ResourceDataInterfaceMap(d, cloudType)["account_id"].(string)
 
 	PollApiUntilSuccess(func() error {
 		_, err := account.Get(client, cloudType, id)
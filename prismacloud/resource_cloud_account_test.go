@@ -23,9 +23,9 @@ func TestAccCloudAccountAws(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudAccountConfig(account.TypeAws, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -56,9 +56,9 @@ func TestAccCloudAccountAzure(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudAccountConfig(account.TypeAzure, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -89,9 +89,9 @@ func TestAccCloudAccountGcp(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudAccountConfig(account.TypeGcp, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -122,9 +122,9 @@ func TestAccCloudAccountAlibaba(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudAccountConfig(account.TypeAlibaba, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -109,18 +109,21 @@ func createComplianceStandard(ctx context.Context, d *schema.ResourceData, meta
 	client := meta.(*pc.Client)
 	o := parseComplianceStandard(d, "")
 
-	if err := standard.Create(client, o); err != nil {
+	created, err := createComplianceStandardObject(client, o)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := standard.Identify(client, o.Name)
-		return err
-	})
+	csId := created.Id
+	if csId == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := standard.Identify(client, o.Name)
+			return err
+		})
 
-	csId, err := standard.Identify(client, o.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if csId, err = standard.Identify(client, o.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -122,18 +122,21 @@ func createComplianceStandardRequirement(ctx context.Context, d *schema.Resource
 	client := meta.(*pc.Client)
 	o := parseComplianceStandardRequirement(d, "")
 
-	if err := requirement.Create(client, o); err != nil {
+	created, err := createComplianceStandardRequirementObject(client, o)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := requirement.Identify(client, o.ComplianceId, o.Name)
-		return err
-	})
+	csrId := created.Id
+	if csrId == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := requirement.Identify(client, o.ComplianceId, o.Name)
+			return err
+		})
 
-	csrId, err := requirement.Identify(client, o.ComplianceId, o.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if csrId, err = requirement.Identify(client, o.ComplianceId, o.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
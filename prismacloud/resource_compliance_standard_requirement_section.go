@@ -142,26 +142,31 @@ func createComplianceStandardRequirementSection(ctx context.Context, d *schema.R
 	client := meta.(*pc.Client)
 	o := parseComplianceStandardRequirementSection(d, "")
 
-	if err := section.Create(client, o); err != nil {
-		diag.FromErr(err)
+	created, err := createComplianceStandardRequirementSectionObject(client, o)
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := section.Get(client, o.RequirementId, o.SectionId)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := section.Get(client, o.RequirementId, o.SectionId)
+			return err
+		})
 
-	liveObj, err := section.Get(client, o.RequirementId, o.SectionId)
-	if err != nil {
-		diag.FromErr(err)
+		liveObj, err := section.Get(client, o.RequirementId, o.SectionId)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		id = liveObj.Id
 	}
 
 	PollApiUntilSuccess(func() error {
-		_, err := section.GetId(client, o.RequirementId, liveObj.Id)
+		_, err := section.GetId(client, o.RequirementId, id)
 		return err
 	})
 
-	d.SetId(TwoStringsToId(o.RequirementId, liveObj.Id))
+	d.SetId(TwoStringsToId(o.RequirementId, id))
 	return readComplianceStandardRequirementSection(ctx, d, meta)
 }
 
@@ -19,9 +19,9 @@ func TestAccComplianceStandardRequirementSection(t *testing.T) {
 	csrs := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccComplianceStandardRequirementSectionDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccComplianceStandardRequirementSectionDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccComplianceStandardRequirementSectionConfig(cs, csr, csrs, "first desc"),
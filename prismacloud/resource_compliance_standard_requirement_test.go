@@ -18,9 +18,9 @@ func TestAccComplianceStandardRequirement(t *testing.T) {
 	csr := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccComplianceStandardRequirementDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccComplianceStandardRequirementDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccComplianceStandardRequirementConfig(cs, csr, "first desc"),
@@ -17,9 +17,9 @@ func TestAccComplianceStandard(t *testing.T) {
 	cs := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccComplianceStandardDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccComplianceStandardDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccComplianceStandardConfig(cs, "cs acctest first desc"),
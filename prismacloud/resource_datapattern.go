@@ -188,18 +188,21 @@ func createDataPattern(ctx context.Context, d *schema.ResourceData, meta interfa
 	client := meta.(*pc.Client)
 	obj := parseDataPattern(d, "")
 
-	if err := datapattern.Create(client, obj); err != nil {
+	created, err := createDataPatternObject(client, obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := datapattern.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := datapattern.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := datapattern.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = datapattern.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -17,9 +17,9 @@ func TestDataPattern(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccDataPatternDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccDataPatternDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDataPatternConfig(name, "desc made by terraform"),
@@ -276,18 +276,21 @@ func createDataProfile(ctx context.Context, d *schema.ResourceData, meta interfa
 	client := meta.(*pc.Client)
 	obj := parseDataProfile(d, "")
 
-	if err := dataprofile.Create(client, obj); err != nil {
+	created, err := createDataProfileObject(client, obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := dataprofile.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := dataprofile.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := dataprofile.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = dataprofile.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -17,9 +17,9 @@ func TestDataProfile(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccDataProfileDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccDataProfileDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccDataProfileConfig(name, "desc made by terraform", "low", "include", "Source Code - javascript", "any"),
@@ -33,9 +33,9 @@ func TestAccEnterpriseSettings(t *testing.T) {
 	var o enterprise.Config
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccEnterpriseSettingsDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccEnterpriseSettingsDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccEnterpriseSettingsConfig(tos[0]),
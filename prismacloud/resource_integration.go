@@ -691,20 +691,24 @@ func createIntegration(ctx context.Context, d *schema.ResourceData, meta interfa
 		prismaIdRequired = false
 	}
 
+	var created integration.Integration
 	err := PollApiUntilSuccessCustom(func() error {
-		err := integration.Create(client, o, prismaIdRequired)
+		var err error
+		created, err = createIntegrationObject(client, o, prismaIdRequired)
 		return err
 	})
 	if err != nil {
 		return err
 	}
-	var id string
 
-	PollApiUntilSuccess(func() error {
-		id1, err := integration.Identify(client, o.Name, prismaIdRequired)
-		id = id1
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			id1, err := integration.Identify(client, o.Name, prismaIdRequired)
+			id = id1
+			return err
+		})
+	}
 	d.SetId(id)
 	return readIntegration(ctx, d, meta)
 }
@@ -17,9 +17,9 @@ func TestAccIntegrationAmazonSqs(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccIntegrationDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccIntegrationDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccIntegrationConfig(name, "amazon_sqs", 1),
@@ -44,9 +44,9 @@ func TestAccIntegrationServiceNow(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccIntegrationDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccIntegrationDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccIntegrationConfig(name, "service_now", 78005),
@@ -71,9 +71,9 @@ func TestAccIntegrationWebhook(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccIntegrationDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccIntegrationDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccIntegrationConfig(name, "webhook", 1),
@@ -98,9 +98,9 @@ func TestAccIntegrationPagerDuty(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccIntegrationDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccIntegrationDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccIntegrationConfig(name, "pager_duty", 1),
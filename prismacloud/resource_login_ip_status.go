@@ -1,27 +1,61 @@
 package prismacloud
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"fmt"
+
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/ip-address"
 	"golang.org/x/net/context"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-func resourceLoginIpStatus() *schema.Resource {
-	return &schema.Resource{
-		CreateContext: createLoginIpStatus,
-		UpdateContext: updateLoginIpStatus,
-		ReadContext:   readLoginIpStatus,
-		DeleteContext: deleteLoginIpStatus,
-		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
-		},
+/*
+loginIpStatusResource is the reference plugin framework resource.  It keeps
+the exact state layout of the SDKv2 implementation it replaced (including the
+implicit "id" attribute), so existing state needs no migration.
+*/
+var (
+	_ resource.Resource                = &loginIpStatusResource{}
+	_ resource.ResourceWithConfigure   = &loginIpStatusResource{}
+	_ resource.ResourceWithImportState = &loginIpStatusResource{}
+)
+
+const loginIpStatusId = "login ip status"
+
+func newLoginIpStatusResource() resource.Resource {
+	return &loginIpStatusResource{}
+}
 
-		Schema: map[string]*schema.Schema{
-			"enabled": {
-				Type:        schema.TypeBool,
+type loginIpStatusResource struct {
+	client *pc.Client
+}
+
+type loginIpStatusModel struct {
+	Id      types.String `tfsdk:"id"`
+	Enabled types.Bool   `tfsdk:"enabled"`
+}
+
+func (r *loginIpStatusResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_trusted_login_ip_status"
+}
+
+func (r *loginIpStatusResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"enabled": schema.BoolAttribute{
 				Required:    true,
 				Description: "Set to true when the ip login is enabled",
 			},
@@ -29,63 +63,100 @@ func resourceLoginIpStatus() *schema.Resource {
 	}
 }
 
-func createLoginIpStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*pc.Client)
-
-	req := ip_address.LoginIpAllowStatus{
-		Enabled: d.Get("enabled").(bool),
+func (r *loginIpStatusResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
 	}
 
-	if err := ip_address.LoginIpStatusUpdate(client, req); err != nil {
-		return diag.FromErr(err)
+	client, ok := req.ProviderData.(*pc.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected provider data",
+			fmt.Sprintf("Expected *prismacloud.Client, got %T", req.ProviderData),
+		)
+		return
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := ip_address.GetLoginIpStatus(client)
-		return err
-	})
+	r.client = client
+}
+
+func (r *loginIpStatusResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan loginIpStatusModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	d.SetId("login ip status")
+	if err := r.set(plan.Enabled.ValueBool()); err != nil {
+		resp.Diagnostics.AddError("Error updating login IP status", err.Error())
+		return
+	}
 
-	return readLoginIpStatus(ctx, d, meta)
+	plan.Id = types.StringValue(loginIpStatusId)
+	resp.Diagnostics.Append(r.read(&plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
-func updateLoginIpStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*pc.Client)
+func (r *loginIpStatusResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state loginIpStatusModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	req := ip_address.LoginIpAllowStatus{
-		Enabled: d.Get("enabled").(bool),
+	resp.Diagnostics.Append(r.read(&state)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *loginIpStatusResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan loginIpStatusModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	if err := ip_address.LoginIpStatusUpdate(client, req); err != nil {
-		return diag.FromErr(err)
+	if err := r.set(plan.Enabled.ValueBool()); err != nil {
+		resp.Diagnostics.AddError("Error updating login IP status", err.Error())
+		return
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := ip_address.GetLoginIpStatus(client)
-		return err
-	})
+	plan.Id = types.StringValue(loginIpStatusId)
+	resp.Diagnostics.Append(r.read(&plan)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
 
-	d.SetId("login ip status")
+// Delete only forgets the resource: the login IP status is a tenant setting.
+func (r *loginIpStatusResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
 
-	return readLoginIpStatus(ctx, d, meta)
+func (r *loginIpStatusResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
-// done
-func readLoginIpStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*pc.Client)
+func (r *loginIpStatusResource) set(enabled bool) error {
+	req := ip_address.LoginIpAllowStatus{
+		Enabled: enabled,
+	}
 
-	info, err := ip_address.GetLoginIpStatus(client)
-	if err != nil {
-		return diag.FromErr(err)
+	if err := ip_address.LoginIpStatusUpdate(r.client, req); err != nil {
+		return err
 	}
 
-	d.Set("enabled", info.Enabled)
+	PollApiUntilSuccess(func() error {
+		_, err := ip_address.GetLoginIpStatus(r.client)
+		return err
+	})
 
 	return nil
 }
 
-// done
-func deleteLoginIpStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	return nil
+func (r *loginIpStatusResource) read(m *loginIpStatusModel) (diags diag.Diagnostics) {
+	info, err := ip_address.GetLoginIpStatus(r.client)
+	if err != nil {
+		diags.AddError("Error reading login IP status", err.Error())
+		return
+	}
+
+	m.Enabled = types.BoolValue(info.Enabled)
+	return
 }
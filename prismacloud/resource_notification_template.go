@@ -99,45 +99,7 @@ func resourceNotificationTemplate() *schema.Resource {
 				Type:        schema.TypeList,
 				Required:    true,
 				Description: "List of template_config",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"basic_config": {
-							Type:     schema.TypeList,
-							Optional: true,
-							Elem: &schema.Resource{
-								Schema: getConfigSchema(),
-							},
-						},
-						"open": {
-							Type:     schema.TypeList,
-							Optional: true,
-							Elem: &schema.Resource{
-								Schema: getConfigSchema(),
-							},
-						},
-						"resolved": {
-							Type:     schema.TypeList,
-							Optional: true,
-							Elem: &schema.Resource{
-								Schema: getConfigSchema(),
-							},
-						},
-						"dismissed": {
-							Type:     schema.TypeList,
-							Optional: true,
-							Elem: &schema.Resource{
-								Schema: getConfigSchema(),
-							},
-						},
-						"snoozed": {
-							Type:     schema.TypeList,
-							Optional: true,
-							Elem: &schema.Resource{
-								Schema: getConfigSchema(),
-							},
-						},
-					},
-				},
+				Elem:        templateConfigSchema(),
 			},
 		},
 	}
@@ -189,9 +151,22 @@ func createNotificationTemplate(ctx context.Context, d *schema.ResourceData, met
 }
 
 func parseNotificationTemplate(d *schema.ResourceData) (string, notification_template.NotificationTemplateRequest) {
+	ntReq := notification_template.NotificationTemplateRequest{
+		IntegrationId:   d.Get("integration_id").(string),
+		IntegrationType: d.Get("integration_type").(string),
+		Name:            d.Get("name").(string),
+		Enabled:         d.Get("enabled").(bool),
+		TemplateType:    d.Get("template_type").(string),
+		TemplateConfig:  parseTemplateConfig(d.Get("template_config").([]interface{})),
+	}
+	return ntReq.Name, ntReq
+}
 
+func parseTemplateConfig(templateConfigMap []interface{}) notification_template.TemplateConfigStruct {
 	templateConfigStruct := notification_template.TemplateConfigStruct{}
-	templateConfigMap := d.Get("template_config").([]interface{})
+	if len(templateConfigMap) == 0 || templateConfigMap[0] == nil {
+		return templateConfigStruct
+	}
 	templateConfigMap2 := templateConfigMap[0].(map[string]interface{})
 	for templateName, configs := range templateConfigMap2 {
 		configsSlice := make([]notification_template.Config, len(configs.([]interface{})))
@@ -211,6 +186,7 @@ func parseNotificationTemplate(d *schema.ResourceData) (string, notification_tem
 			}
 			configsSlice[i] = notification_template.Config{
 				FieldName:      config.(map[string]interface{})["field_name"].(string),
+				AliasField:     config.(map[string]interface{})["alias_field"].(string),
 				Options:        ternaryOperator(len(optionsSlice) > 0, optionsSlice, make([]notification_template.Option, 0)).([]notification_template.Option),
 				DisplayName:    config.(map[string]interface{})["display_name"].(string),
 				Type:           config.(map[string]interface{})["type"].(string),
@@ -236,16 +212,7 @@ func parseNotificationTemplate(d *schema.ResourceData) (string, notification_tem
 			log.Printf("[WARN]: State mapping not found for: %+v\n, Valid States are: [basic_config, resolved, dismissed , open, snoozed]", configType)
 		}
 	}
-
-	ntReq := notification_template.NotificationTemplateRequest{
-		IntegrationId:   d.Get("integration_id").(string),
-		IntegrationType: d.Get("integration_type").(string),
-		Name:            d.Get("name").(string),
-		Enabled:         d.Get("enabled").(bool),
-		TemplateType:    d.Get("template_type").(string),
-		TemplateConfig:  templateConfigStruct,
-	}
-	return ntReq.Name, ntReq
+	return templateConfigStruct
 }
 
 func readNotificationTemplate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -341,6 +308,50 @@ func ternaryOperator(flag bool, obj1 interface{}, obj2 interface{}) interface{}
 	}
 	return obj2
 }
+
+// templateConfigSchema is the template_config of a notification template.
+func templateConfigSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"basic_config": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: getConfigSchema(),
+				},
+			},
+			"open": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: getConfigSchema(),
+				},
+			},
+			"resolved": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: getConfigSchema(),
+				},
+			},
+			"dismissed": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: getConfigSchema(),
+				},
+			},
+			"snoozed": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: getConfigSchema(),
+				},
+			},
+		},
+	}
+}
+
 func getConfigSchema() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		"field_name": {
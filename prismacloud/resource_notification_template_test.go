@@ -0,0 +1,47 @@
+package prismacloud
+
+import (
+	"reflect"
+	"testing"
+
+	notification_template "github.com/paloaltonetworks/prisma-cloud-go/notification-template"
+)
+
+func TestParseTemplateConfig(t *testing.T) {
+	field := func(name, alias, value string) map[string]interface{} {
+		return map[string]interface{}{
+			"field_name":      name,
+			"alias_field":     alias,
+			"display_name":    "",
+			"type":            "text",
+			"value":           value,
+			"redlock_mapping": true,
+			"required":        false,
+			"type_ahead_uri":  "",
+			"max_length":      0,
+			"options":         []interface{}{},
+		}
+	}
+
+	got := parseTemplateConfig([]interface{}{
+		map[string]interface{}{
+			"basic_config": []interface{}{field("summary", "", "<$PolicyName>")},
+			"open":         []interface{}{field("account", "$AccountName", "")},
+		},
+	})
+	want := notification_template.TemplateConfigStruct{
+		BasicConfig: []notification_template.Config{
+			{FieldName: "summary", Type: "text", Value: "<$PolicyName>", RedlockMapping: true, Options: []notification_template.Option{}},
+		},
+		Open: []notification_template.Config{
+			{FieldName: "account", AliasField: "$AccountName", Type: "text", RedlockMapping: true, Options: []notification_template.Option{}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nexpected:\n%#v", got, want)
+	}
+
+	if got := parseTemplateConfig(nil); !reflect.DeepEqual(got, notification_template.TemplateConfigStruct{}) {
+		t.Errorf("Got %#v for no template config", got)
+	}
+}
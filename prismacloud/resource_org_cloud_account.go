@@ -867,7 +867,7 @@ func saveOrgCloudAccount(d *schema.ResourceData, dest string, obj interface{}) {
 
 func createOrgCloudAccount(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
-	cloudType, name, obj := parseOrgCloudAccount(d)
+	cloudType, _, obj := parseOrgCloudAccount(d)
 	if err := org.Create(client, obj); err != nil {
 		if strings.Contains(err.Error(), "duplicate_cloud_account") {
 			if err := org.Update(client, obj); err != nil {
@@ -877,15 +877,8 @@ func createOrgCloudAccount(ctx context.Context, d *schema.ResourceData, meta int
 			return diag.FromErr(err)
 		}
 	}
-	PollApiUntilSuccess(func() error {
-		_, err := org.Identify(client, cloudType, name)
-		return err
-	})
-
-	id, err := org.Identify(client, cloudType, name)
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	// The cloud account ID is the one in the config.
+	id := ResourceDataInterfaceMap(d, cloudType)["account_id"].(string)
 
 	PollApiUntilSuccess(func() error {
 		_, err := org.Get(client, cloudType, id)
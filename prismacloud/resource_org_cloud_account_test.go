@@ -22,9 +22,9 @@ func TestAccCloudAccountAwsorg(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudorgAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudorgAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudorgAccountConfig(org.TypeAwsOrg, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -55,9 +55,9 @@ func TestAccCloudAccountAzureorg(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudorgAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudorgAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudorgAccountConfig(org.TypeAzureOrg, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -88,9 +88,9 @@ func TestAccCloudAccountGcporg(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudorgAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudorgAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudorgAccountConfig(org.TypeGcpOrg, g1, g2, name, []string{"prismacloud_account_group.x.group_id"}),
@@ -121,9 +121,9 @@ func TestAccCloudAccountOci(t *testing.T) {
 	}
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccCloudorgAccountDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCloudorgAccountDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudorgAccountConfig(org.TypeOci, g1, g2, name, nil),
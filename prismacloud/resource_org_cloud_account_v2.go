@@ -1037,7 +1037,7 @@ func saveOrgV2CloudAccount(d *schema.ResourceData, dest string, obj interface{})
 }
 func createOrgV2CloudAccount(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
-	cloudType, name, _, obj := parseOrgV2CloudAccount(d)
+	cloudType, _, accId, obj := parseOrgV2CloudAccount(d)
 
 	if err := org.Create(client, obj); err != nil {
 		if strings.Contains(err.Error(), "duplicate_cloud_account") {
@@ -1048,16 +1048,6 @@ func createOrgV2CloudAccount(ctx context.Context, d *schema.ResourceData, meta i
 			return diag.FromErr(err)
 		}
 	}
-	PollApiUntilSuccess(func() error {
-		_, err := org.Identify(client, cloudType, name)
-		return err
-	})
-
-	accId, err := org.Identify(client, cloudType, name)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-
 	var resp1 interface{}
 	PollApiUntilSuccess(func() error {
 		resp, err := org.Get(client, cloudType, accId)
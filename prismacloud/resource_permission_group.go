@@ -212,18 +212,21 @@ func createPermissionGroup(ctx context.Context, d *schema.ResourceData, meta int
 	client := meta.(*pc.Client)
 	obj := parsePermissionGroup(d)
 
-	if err := permission_group.Create(client, obj); err != nil {
+	created, err := createPermissionGroupObject(client, obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := permission_group.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := permission_group.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := permission_group.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = permission_group.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	var resp1 permission_group.PermissionGroup
@@ -1,16 +1,21 @@
 package prismacloud
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"golang.org/x/net/context"
 
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account"
 	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/history"
+	"github.com/paloaltonetworks/prisma-cloud-go/timerange"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -24,6 +29,8 @@ func resourcePolicy() *schema.Resource {
 		UpdateContext: updatePolicy,
 		DeleteContext: deletePolicy,
 
+		CustomizeDiff: customizeDiffPolicy,
+
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
 			Update: schema.DefaultTimeout(10 * time.Minute),
@@ -242,9 +249,26 @@ func resourcePolicy() *schema.Resource {
 							Description: "Resource ID path",
 						},
 						"criteria": {
+							Type:                  schema.TypeString,
+							Optional:              true,
+							Computed:              true,
+							Description:           "Saved search ID that defines the rule criteria",
+							ValidateFunc:          validateRqlCriteria,
+							DiffSuppressFunc:      suppressEquivalentJsonDiffs,
+							DiffSuppressOnRefresh: true,
+						},
+						"rql": {
+							Type:          schema.TypeString,
+							Optional:      true,
+							Description:   "RQL query that defines the rule criteria, saved as a search managed by this resource",
+							ValidateFunc:  validateRql,
+							ConflictsWith: []string{"rule.0.criteria"},
+						},
+						"time_range": timeRangeSchema("resource_policy"),
+						"search_id": {
 							Type:        schema.TypeString,
-							Optional:    true,
-							Description: "Saved search ID that defines the rule criteria",
+							Computed:    true,
+							Description: "ID of the saved search managed for rql",
 						},
 						"data_criteria": {
 							Type:        schema.TypeList,
@@ -315,19 +339,24 @@ func resourcePolicy() *schema.Resource {
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"criteria": {
-										Type:        schema.TypeString,
-										Optional:    true,
-										Description: "Criteria for build policy",
+										Type:         schema.TypeString,
+										Optional:     true,
+										Description:  "Criteria for build policy",
+										ValidateFunc: validateRqlCriteria,
 									},
 									"metadata": {
-										Type:        schema.TypeMap,
-										Optional:    true,
-										Computed:    true,
-										Description: "YAML string for code build policy",
+										Type:                  schema.TypeMap,
+										Optional:              true,
+										Computed:              true,
+										Description:           "YAML string for code build policy",
+										DiffSuppressFunc:      suppressEquivalentYamlDiffs,
+										DiffSuppressOnRefresh: true,
+										ValidateFunc:          validateBuildPolicyMetadata,
 										Elem: &schema.Schema{
 											Type: schema.TypeString,
 										},
 									},
+									"conditions": buildPolicyConditionsSchema(true),
 									"type": {
 										Type:        schema.TypeString,
 										Optional:    true,
@@ -376,9 +405,12 @@ func resourcePolicy() *schema.Resource {
 							Description: "CLI script template",
 						},
 						"cli_script_json_schema_string": {
-							Type:        schema.TypeString,
-							Optional:    true,
-							Description: "CLI script JSON schema",
+							Type:                  schema.TypeString,
+							Optional:              true,
+							Description:           "CLI script JSON schema",
+							DiffSuppressFunc:      suppressEquivalentJsonDiffs,
+							DiffSuppressOnRefresh: true,
+							ValidateFunc:          validateCliScriptJsonSchema,
 						},
 						"actions": {
 							Type:     schema.TypeList,
@@ -390,8 +422,10 @@ func resourcePolicy() *schema.Resource {
 										Optional: true,
 									},
 									"payload": {
-										Type:     schema.TypeString,
-										Optional: true,
+										Type:                  schema.TypeString,
+										Optional:              true,
+										DiffSuppressFunc:      suppressEquivalentJsonDiffs,
+										DiffSuppressOnRefresh: true,
 									},
 								},
 							},
@@ -467,7 +501,148 @@ func resourcePolicy() *schema.Resource {
 	}
 }
 
-func parsePolicy(d *schema.ResourceData, id string) policy.Policy {
+// customizeDiffPolicy runs the plan time checks of a policy.
+func customizeDiffPolicy(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if err := customizeDiffPolicyRql(ctx, d, meta); err != nil {
+		return err
+	}
+	if err := customizeDiffPolicyBuildConditions(ctx, d, meta); err != nil {
+		return err
+	}
+
+	return customizeDiffPolicyRemediation(ctx, d, meta)
+}
+
+// customizeDiffPolicyBuildConditions checks the conditions given instead of
+// a child's metadata.
+func customizeDiffPolicyBuildConditions(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	raw := d.GetRawConfig()
+	for i := 0; i < d.Get("rule.0.children.#").(int); i++ {
+		key := fmt.Sprintf("rule.0.children.%d.conditions", i)
+		if !policyChildHas(raw, i, "conditions") || !d.NewValueKnown(key) {
+			continue
+		}
+		if policyChildHas(raw, i, "metadata") {
+			return fmt.Errorf("rule.0.children.%d: only one of metadata and conditions may be given", i)
+		}
+		if _, err := buildPolicyCode(d.Get(key).([]interface{})); err != nil {
+			return fmt.Errorf("%s: %s", key, err)
+		}
+	}
+
+	return nil
+}
+
+// customizeDiffPolicyRemediation checks the CLI script template against its
+// JSON schema.
+func customizeDiffPolicyRemediation(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("remediation.0.cli_script_template") || !d.NewValueKnown("remediation.0.cli_script_json_schema_string") {
+		return nil
+	}
+
+	template := d.Get("remediation.0.cli_script_template").(string)
+	if err := checkCliScriptTemplate(template, d.Get("remediation.0.cli_script_json_schema_string").(string)); err != nil {
+		return fmt.Errorf("remediation.0.cli_script_template: %s", err)
+	}
+
+	return nil
+}
+
+// customizeDiffPolicyRql checks that the rule's RQL fits the rule type.
+func customizeDiffPolicyRql(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("rule.0.rule_type") {
+		return nil
+	}
+	ruleType := d.Get("rule.0.rule_type").(string)
+
+	if d.NewValueKnown("rule.0.rql") {
+		if rql := d.Get("rule.0.rql").(string); rql != "" {
+			if _, err := policySearchType(ruleType); err != nil {
+				return err
+			}
+			if err := checkRqlRuleType(rql, ruleType); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !d.NewValueKnown("rule.0.criteria") {
+		return nil
+	}
+
+	return checkRqlRuleType(d.Get("rule.0.criteria").(string), ruleType)
+}
+
+// policySearchType returns the search type of the rql of a rule type.
+func policySearchType(ruleType string) (string, error) {
+	searchType, ok := rqlRuleTypes[ruleType]
+	if !ok {
+		return "", fmt.Errorf("rql is not supported for rule_type %q", ruleType)
+	}
+
+	return searchType, nil
+}
+
+// policySearchName returns the name of the saved search managed for a
+// policy.  Saved search names are unique, so the name includes a hash of the
+// search: this lets the new search exist alongside the one it replaces.
+func policySearchName(name, query string, tr timerange.TimeRange) string {
+	b, _ := json.Marshal(tr)
+	sum := sha256.Sum256(append([]byte(query), b...))
+
+	return fmt.Sprintf("%s (%x)", name, sum[:4])
+}
+
+// createPolicySearch saves the rule's rql as a new saved search and returns
+// its ID.
+func createPolicySearch(client *pc.Client, d *schema.ResourceData) (string, error) {
+	rspec := d.Get("rule").([]interface{})[0].(map[string]interface{})
+	query := rspec["rql"].(string)
+	tr := rqlTimeRange(rspec["time_range"])
+	name := d.Get("name").(string)
+
+	searchType, err := policySearchType(rspec["rule_type"].(string))
+	if err != nil {
+		return "", err
+	}
+
+	id, err := runRqlSearch(client, searchType, query, tr)
+	if err != nil {
+		return "", err
+	}
+
+	saved, err := history.Save(client, history.SavedSearch{
+		Id:          id,
+		Name:        policySearchName(name, query, tr),
+		Query:       query,
+		TimeRange:   tr,
+		Description: fmt.Sprintf("Criteria of the %q policy", name),
+		CloudType:   d.Get("cloud_type").(string),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if saved.Id != "" {
+		id = saved.Id
+	}
+
+	return id, nil
+}
+
+// deletePolicySearch removes a managed saved search.  Failing to do so
+// leaves an unused saved search behind, so it's only logged.
+func deletePolicySearch(client *pc.Client, id string) {
+	if id == "" {
+		return
+	}
+
+	if err := history.Delete(client, id); err != nil && err != pc.ObjectNotFoundError {
+		log.Printf("[WARN] Failed to delete saved search %q: %s", id, err)
+	}
+}
+
+func parsePolicy(d *schema.ResourceData, id string) (policy.Policy, diag.Diagnostics) {
 	rspec := d.Get("rule").([]interface{})[0].(map[string]interface{})
 	ps := d.Get("policy_subtypes")
 	ans := policy.Policy{
@@ -507,14 +682,49 @@ func parsePolicy(d *schema.ResourceData, id string) policy.Policy {
 		}
 	}
 
+	raw := d.GetRawConfig()
 	cld := rspec["children"].([]interface{})
 	ans.Rule.Children = make([]policy.Children, 0, len(cld))
-	for _, chi := range cld {
+	for i, chi := range cld {
 		cl := chi.(map[string]interface{})
+		path := cty.GetAttrPath("rule").IndexInt(0).GetAttr("children").IndexInt(i)
 		var md policy.Metadata
-		err := mapstructure.Decode(cl["metadata"], &md)
-		if err != nil {
-			panic(err)
+		var err error
+		if policyChildHas(raw, i, "conditions") {
+			// The state has the conditions computed from the old metadata,
+			// so only the config tells which one was given.
+			if policyChildHas(raw, i, "metadata") {
+				return ans, diag.Diagnostics{{
+					Severity:      diag.Error,
+					Summary:       "Conflicting build policy definitions",
+					Detail:        "Only one of metadata and conditions may be given",
+					AttributePath: path,
+				}}
+			}
+			if md.Code, err = buildPolicyCode(cl["conditions"].([]interface{})); err != nil {
+				return ans, diag.Diagnostics{{
+					Severity:      diag.Error,
+					Summary:       "Invalid build policy conditions",
+					Detail:        err.Error(),
+					AttributePath: path.GetAttr("conditions"),
+				}}
+			}
+		} else {
+			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				ErrorUnused: true,
+				Result:      &md,
+			})
+			if err == nil {
+				err = dec.Decode(cl["metadata"])
+			}
+			if err != nil {
+				return ans, diag.Diagnostics{{
+					Severity:      diag.Error,
+					Summary:       "Invalid build policy metadata",
+					Detail:        err.Error(),
+					AttributePath: path.GetAttr("metadata"),
+				}}
+			}
 		}
 		ans.Rule.Children = append(ans.Rule.Children, policy.Children{
 			Criteria:       cl["criteria"].(string),
@@ -541,8 +751,15 @@ func parsePolicy(d *schema.ResourceData, id string) policy.Policy {
 				ans.Remediation.Actions = actions
 			}
 			var csjs interface{}
-			if err := json.Unmarshal([]byte(rems["cli_script_json_schema_string"].(string)), &csjs); err != nil {
-				log.Printf("[WARN] Error unmarshalling 'cli_script_json_schema_string' for %q: %s", d.Id(), err)
+			if s := rems["cli_script_json_schema_string"].(string); s != "" {
+				if err := json.Unmarshal([]byte(s), &csjs); err != nil {
+					return ans, diag.Diagnostics{{
+						Severity:      diag.Error,
+						Summary:       "Invalid CLI script JSON schema",
+						Detail:        err.Error(),
+						AttributePath: cty.GetAttrPath("remediation").IndexInt(0).GetAttr("cli_script_json_schema_string"),
+					}}
+				}
 			}
 			ans.Remediation.CliScriptJsonSchema = csjs
 		}
@@ -565,10 +782,10 @@ func parsePolicy(d *schema.ResourceData, id string) policy.Policy {
 		})
 	}
 
-	return ans
+	return ans, nil
 }
 
-func savePolicy(d *schema.ResourceData, obj policy.Policy) {
+func savePolicy(d *schema.ResourceData, obj policy.Policy, managed *history.Query) {
 	d.Set("policy_id", obj.PolicyId)
 	d.Set("name", obj.Name)
 	d.Set("policy_type", obj.PolicyType)
@@ -646,6 +863,7 @@ func savePolicy(d *schema.ResourceData, obj policy.Policy) {
 			"type":           chi.Type,
 			"recommendation": chi.Recommendation,
 			"metadata":       map[string]string{"code": chi.Metadata.Code},
+			"conditions":     flattenBuildPolicyConditions(chi.Metadata.Code),
 		})
 	}
 	rv["children"] = cld
@@ -663,6 +881,17 @@ func savePolicy(d *schema.ResourceData, obj policy.Policy) {
 	}
 	rv["parameters"] = pm
 
+	// Inline RQL.  Only the query is read back from the saved search, as the
+	// API normalizes the time range.
+	if managed != nil {
+		rv["rql"] = managed.Query
+		rv["search_id"] = managed.Id
+		rv["time_range"] = x["time_range"]
+		if v, ok := obj.Rule.Criteria.(string); ok {
+			rv["criteria"] = v
+		}
+	}
+
 	if err := d.Set("rule", []interface{}{rv}); err != nil {
 		log.Printf("[WARN] Error setting 'rule' for %q: %s", d.Id(), err)
 	}
@@ -729,22 +958,41 @@ func savePolicy(d *schema.ResourceData, obj policy.Policy) {
 
 func createPolicy(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
-	obj := parsePolicy(d, "")
+	obj, diags := parsePolicy(d, "")
+	if diags.HasError() {
+		return diags
+	}
 
+	var searchId string
+	if d.Get("rule.0.rql").(string) != "" {
+		var err error
+		if searchId, err = createPolicySearch(client, d); err != nil {
+			return diag.FromErr(err)
+		}
+		obj.Rule.Criteria = searchId
+	}
+
+	var created policy.Policy
 	if diags := RetryWithBackoff(client, func() error {
-		return policy.Create(client, obj)
+		var err error
+		created, err = createPolicyObject(client, obj)
+		return err
 	}); diags != nil {
+		deletePolicySearch(client, searchId)
 		return diags
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := policy.Identify(client, obj.Name)
-		return err
-	})
+	id := created.PolicyId
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := policy.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := policy.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		var err error
+		if id, err = policy.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -775,7 +1023,22 @@ func readPolicy(ctx context.Context, d *schema.ResourceData, meta interface{}) d
 		return diags
 	}
 
-	savePolicy(d, obj)
+	var managed *history.Query
+	if criteria, ok := obj.Rule.Criteria.(string); ok && d.Get("rule.0.rql").(string) != "" {
+		managed = &history.Query{}
+		if diags := RetryWithBackoff(client, func() error {
+			q, err := history.Get(client, criteria)
+			if err == pc.ObjectNotFoundError {
+				return nil
+			}
+			*managed = q
+			return err
+		}); diags != nil {
+			return diags
+		}
+	}
+
+	savePolicy(d, obj, managed)
 
 	return nil
 }
@@ -783,21 +1046,49 @@ func readPolicy(ctx context.Context, d *schema.ResourceData, meta interface{}) d
 func updatePolicy(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
 	id := d.Id()
-	obj := parsePolicy(d, id)
+	obj, diags := parsePolicy(d, id)
+	if diags.HasError() {
+		return diags
+	}
+
+	oldSearchId := d.Get("rule.0.search_id").(string)
+	searchId := oldSearchId
+	if d.Get("rule.0.rql").(string) == "" {
+		searchId = ""
+	} else if searchId == "" || d.HasChanges("rule.0.rql", "rule.0.time_range") {
+		var err error
+		if searchId, err = createPolicySearch(client, d); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if searchId != "" {
+		obj.Rule.Criteria = searchId
+	}
 
 	if diags := RetryWithBackoff(client, func() error {
 		return policy.Update(client, obj)
 	}); diags != nil {
+		if searchId != oldSearchId {
+			deletePolicySearch(client, searchId)
+		}
 		return diags
 	}
 
+	// The old saved search is deleted once the policy no longer uses it.
+	if criteria, _ := obj.Rule.Criteria.(string); oldSearchId != "" && criteria != oldSearchId {
+		deletePolicySearch(client, oldSearchId)
+	}
+
 	return readPolicy(ctx, d, meta)
 }
 
 func deletePolicy(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
 	id := d.Id()
-	obj := parsePolicy(d, "")
+	obj, diags := parsePolicy(d, "")
+	if diags.HasError() {
+		return diags
+	}
 
 	if diags := RetryWithBackoff(client, func() error {
 		err := policy.Delete(client, id, obj)
@@ -809,6 +1100,8 @@ func deletePolicy(ctx context.Context, d *schema.ResourceData, meta interface{})
 		return diags
 	}
 
+	deletePolicySearch(client, d.Get("rule.0.search_id").(string))
+
 	d.SetId("")
 	return nil
 }
@@ -0,0 +1,167 @@
+package prismacloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourcePolicyComplianceMapping() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createPolicyComplianceMapping,
+		ReadContext:   readPolicyComplianceMapping,
+		DeleteContext: deletePolicyComplianceMapping,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importPolicyComplianceMapping,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"policy_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Policy ID",
+			},
+			"compliance_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Compliance section UUID",
+			},
+
+			// Output.
+			"standard_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Compliance standard name",
+			},
+			"requirement_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Requirement ID",
+			},
+			"requirement_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Requirement name",
+			},
+			"section_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Section ID",
+			},
+		},
+	}
+}
+
+func importPolicyComplianceMapping(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if len(strings.Split(d.Id(), IdSeparator)) != 2 {
+		return nil, fmt.Errorf("Expected an ID of the form <policy_id>%s<compliance_id>, got %q", IdSeparator, d.Id())
+	}
+
+	policyId, complianceId := IdToTwoStrings(d.Id())
+	d.Set("policy_id", policyId)
+	d.Set("compliance_id", complianceId)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// modifyPolicyComplianceMapping adds or removes a single compliance section
+// from the policy's live compliance metadata.
+func modifyPolicyComplianceMapping(client *pc.Client, policyId, complianceId string, add bool) error {
+	objectLocks.Lock("policy/" + policyId)
+	defer objectLocks.Unlock("policy/" + policyId)
+
+	obj, err := policy.Get(client, policyId)
+	if err != nil {
+		return err
+	}
+
+	list := make([]policy.ComplianceMetadata, 0, len(obj.ComplianceMetadata)+1)
+	for _, o := range obj.ComplianceMetadata {
+		if o.ComplianceId != complianceId {
+			list = append(list, o)
+		}
+	}
+	if add {
+		if len(list) != len(obj.ComplianceMetadata) {
+			return nil
+		}
+		list = append(list, policy.ComplianceMetadata{ComplianceId: complianceId})
+	} else if len(list) == len(obj.ComplianceMetadata) {
+		return nil
+	}
+	obj.ComplianceMetadata = list
+
+	return policy.Update(client, obj)
+}
+
+func createPolicyComplianceMapping(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	policyId := d.Get("policy_id").(string)
+	complianceId := d.Get("compliance_id").(string)
+
+	if diags := RetryWithBackoff(client, func() error {
+		return modifyPolicyComplianceMapping(client, policyId, complianceId, true)
+	}); diags != nil {
+		return diags
+	}
+
+	d.SetId(TwoStringsToId(policyId, complianceId))
+	return readPolicyComplianceMapping(ctx, d, meta)
+}
+
+func readPolicyComplianceMapping(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	policyId, complianceId := IdToTwoStrings(d.Id())
+
+	obj, err := policy.Get(client, policyId)
+	if err != nil {
+		if err == pc.ObjectNotFoundError {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	for _, o := range obj.ComplianceMetadata {
+		if o.ComplianceId == complianceId {
+			d.Set("policy_id", policyId)
+			d.Set("compliance_id", complianceId)
+			d.Set("standard_name", o.StandardName)
+			d.Set("requirement_id", o.RequirementId)
+			d.Set("requirement_name", o.RequirementName)
+			d.Set("section_id", o.SectionId)
+			return nil
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func deletePolicyComplianceMapping(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	policyId, complianceId := IdToTwoStrings(d.Id())
+
+	if diags := RetryWithBackoff(client, func() error {
+		err := modifyPolicyComplianceMapping(client, policyId, complianceId, false)
+		if err == pc.ObjectNotFoundError {
+			return nil
+		}
+		return err
+	}); diags != nil {
+		return diags
+	}
+
+	d.SetId("")
+	return nil
+}
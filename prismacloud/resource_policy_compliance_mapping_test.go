@@ -0,0 +1,122 @@
+package prismacloud
+
+import (
+	"fmt"
+	"testing"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccPolicyComplianceMapping(t *testing.T) {
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccPolicyComplianceMappingDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPolicyComplianceMappingConfig(name),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPolicyComplianceMappingExists("prismacloud_policy_compliance_mapping.test"),
+					resource.TestCheckResourceAttr("prismacloud_policy_compliance_mapping.test", "standard_name", name),
+				),
+			},
+			{
+				ResourceName:      "prismacloud_policy_compliance_mapping.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckPolicyComplianceMappingExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Resource not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("Object label ID is not set")
+		}
+
+		client := testAccProvider.Meta().(*pc.Client)
+		policyId, complianceId := IdToTwoStrings(rs.Primary.ID)
+		lo, err := policy.Get(client, policyId)
+		if err != nil {
+			return fmt.Errorf("Error in get: %s", err)
+		}
+
+		for _, o := range lo.ComplianceMetadata {
+			if o.ComplianceId == complianceId {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Section %q is not mapped to policy %q", complianceId, policyId)
+	}
+}
+
+func testAccPolicyComplianceMappingDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*pc.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "prismacloud_policy_compliance_mapping" {
+			continue
+		}
+
+		policyId, complianceId := IdToTwoStrings(rs.Primary.ID)
+		lo, err := policy.Get(client, policyId)
+		if err != nil {
+			continue
+		}
+		for _, o := range lo.ComplianceMetadata {
+			if o.ComplianceId == complianceId {
+				return fmt.Errorf("Section %q is still mapped to policy %q", complianceId, policyId)
+			}
+		}
+	}
+
+	return nil
+}
+
+func testAccPolicyComplianceMappingConfig(name string) string {
+	return fmt.Sprintf(`
+data "prismacloud_policies" "x" {
+    filters = {
+        "policy.type": "config",
+        "policy.policyMode": "redlock_default",
+    }
+}
+
+resource "prismacloud_compliance_standard" "x" {
+    name = %q
+    description = "mapping acctest"
+}
+
+resource "prismacloud_compliance_standard_requirement" "x" {
+    cs_id = prismacloud_compliance_standard.x.cs_id
+    name = %q
+    description = "mapping acctest"
+    requirement_id = "1.1"
+}
+
+resource "prismacloud_compliance_standard_requirement_section" "x" {
+    csr_id = prismacloud_compliance_standard_requirement.x.csr_id
+    section_id = "1.1.1"
+    description = "mapping acctest"
+}
+
+resource "prismacloud_policy_compliance_mapping" "test" {
+    policy_id = data.prismacloud_policies.x.listing[0].policy_id
+    compliance_id = prismacloud_compliance_standard_requirement_section.x.csrs_id
+}
+`, name, name)
+}
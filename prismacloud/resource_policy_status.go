@@ -0,0 +1,475 @@
+package prismacloud
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourcePolicyStatus() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createPolicyStatus,
+		ReadContext:   readPolicyStatus,
+		UpdateContext: updatePolicyStatus,
+		DeleteContext: deletePolicyStatus,
+
+		CustomizeDiff: customizeDiffPolicyStatus,
+
+		Schema: map[string]*schema.Schema{
+			"policy_ids": {
+				Type:         schema.TypeSet,
+				Optional:     true,
+				Description:  "IDs of the policies to manage",
+				ExactlyOneOf: []string{"policy_ids", "filter"},
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"filter": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Select system default policies matching all of these",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"labels": {
+							Type:        schema.TypeSet,
+							Optional:    true,
+							Description: "Policies having any of these labels",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"compliance_standard": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Compliance standard name",
+						},
+						"cloud_type": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Cloud type",
+						},
+						"policy_type": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Policy type",
+							ValidateFunc: validation.StringInSlice(
+								[]string{
+									policy.PolicyTypeConfig,
+									policy.PolicyTypeAuditEvent,
+									policy.PolicyTypeNetwork,
+									policy.PolicyTypeIAM,
+									policy.PolicyTypeAnomaly,
+									policy.PolicyTypeData,
+									policy.PolicyTypeAttackPath,
+								},
+								false,
+							),
+						},
+					},
+				},
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Required:    true,
+				Description: "Enabled",
+			},
+			"severity": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Severity override",
+				ValidateFunc: validation.StringInSlice(
+					[]string{
+						policy.SeverityLow,
+						policy.SeverityMedium,
+						policy.SeverityHigh,
+						policy.SeverityCritical,
+						policy.SeverityInformational,
+					},
+					false,
+				),
+			},
+
+			// Output.
+			"policies": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The managed policies and their original state",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"policy_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Policy ID",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Policy name",
+						},
+						"original_enabled": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Enabled before this resource managed the policy",
+						},
+						"original_severity": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Severity before this resource managed the policy",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// policyStatusRecord is the original state of a managed policy.
+type policyStatusRecord struct {
+	Id       string
+	Name     string
+	Enabled  bool
+	Severity string
+}
+
+func loadPolicyStatusRecords(d *schema.ResourceData) []policyStatusRecord {
+	return parsePolicyStatusRecords(d.Get("policies").([]interface{}))
+}
+
+// priorPolicyStatusRecords returns the records as they were before the
+// apply.  When the filter matches change, "policies" is planned as unknown,
+// so d.Get has nothing to give.
+func priorPolicyStatusRecords(d *schema.ResourceData) []policyStatusRecord {
+	o, _ := d.GetChange("policies")
+	return parsePolicyStatusRecords(o.([]interface{}))
+}
+
+func parsePolicyStatusRecords(list []interface{}) []policyStatusRecord {
+	ans := make([]policyStatusRecord, 0, len(list))
+	for _, x := range list {
+		m := x.(map[string]interface{})
+		ans = append(ans, policyStatusRecord{
+			Id:       m["policy_id"].(string),
+			Name:     m["name"].(string),
+			Enabled:  m["original_enabled"].(bool),
+			Severity: m["original_severity"].(string),
+		})
+	}
+
+	return ans
+}
+
+func savePolicyStatusRecords(d *schema.ResourceData, list []policyStatusRecord) {
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+
+	ans := make([]interface{}, 0, len(list))
+	for _, o := range list {
+		ans = append(ans, map[string]interface{}{
+			"policy_id":         o.Id,
+			"name":              o.Name,
+			"original_enabled":  o.Enabled,
+			"original_severity": o.Severity,
+		})
+	}
+
+	if err := d.Set("policies", ans); err != nil {
+		log.Printf("[WARN] Error setting 'policies' for %q: %s", d.Id(), err)
+	}
+}
+
+// policyStatusSelection returns the IDs of the selected policies.
+func policyStatusSelection(client *pc.Client, ids *schema.Set, filter []interface{}) ([]string, error) {
+	if ids != nil && ids.Len() != 0 {
+		ans := SetToStringSlice(ids)
+		sort.Strings(ans)
+		return ans, nil
+	}
+
+	if len(filter) == 0 || filter[0] == nil {
+		return nil, nil
+	}
+	spec := filter[0].(map[string]interface{})
+
+	query := make(map[string]string)
+	if v := spec["compliance_standard"].(string); v != "" {
+		query["policy.complianceStandard"] = v
+	}
+	if v := spec["cloud_type"].(string); v != "" {
+		query["cloud.type"] = v
+	}
+	if v := spec["policy_type"].(string); v != "" {
+		query["policy.type"] = v
+	}
+
+	// The listing takes a single label, so labels are queried one at a time.
+	labels := SetToStringSlice(spec["labels"].(*schema.Set))
+	if len(labels) == 0 {
+		labels = []string{""}
+	}
+
+	found := make(map[string]bool)
+	for _, label := range labels {
+		q := make(map[string]string, len(query)+1)
+		for k, v := range query {
+			q[k] = v
+		}
+		if label != "" {
+			q["policy.label"] = label
+		}
+
+		list, err := policy.List(client, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range list {
+			if o.SystemDefault {
+				found[o.PolicyId] = true
+			}
+		}
+	}
+
+	ans := make([]string, 0, len(found))
+	for k := range found {
+		ans = append(ans, k)
+	}
+	sort.Strings(ans)
+
+	return ans, nil
+}
+
+// setPolicyStatus sets enabled and, if given, the severity of a policy.
+func setPolicyStatus(client *pc.Client, id string, enabled bool, severity string) error {
+	objectLocks.Lock("policy/" + id)
+	defer objectLocks.Unlock("policy/" + id)
+
+	obj, err := policy.Get(client, id)
+	if err != nil {
+		return err
+	}
+
+	if obj.Enabled == enabled && (severity == "" || obj.Severity == severity) {
+		return nil
+	}
+
+	obj.Enabled = enabled
+	if severity != "" {
+		obj.Severity = severity
+	}
+
+	return policy.Update(client, obj)
+}
+
+// applyPolicyStatus brings the selected policies to the configured state,
+// recording the original state of policies it has not seen before and
+// restoring the policies that are no longer selected.
+func applyPolicyStatus(client *pc.Client, d *schema.ResourceData) diag.Diagnostics {
+	ids, err := policyStatusSelection(client, d.Get("policy_ids").(*schema.Set), d.Get("filter").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(ids) == 0 {
+		return diag.Errorf("no policies match the given selection")
+	}
+
+	enabled := d.Get("enabled").(bool)
+	severity := d.Get("severity").(string)
+
+	records := make(map[string]policyStatusRecord)
+	for _, o := range priorPolicyStatusRecords(d) {
+		records[o.Id] = o
+	}
+
+	// Records are saved as they change, so that a partial failure does not
+	// lose the original state of the policies already changed.
+	save := func() {
+		list := make([]policyStatusRecord, 0, len(records))
+		for _, o := range records {
+			list = append(list, o)
+		}
+		savePolicyStatusRecords(d, list)
+	}
+	defer save()
+
+	for k, o := range records {
+		if stringInSlice(k, ids) {
+			continue
+		}
+		if diags := RetryWithBackoff(client, func() error {
+			err := setPolicyStatus(client, o.Id, o.Enabled, o.Severity)
+			if err == pc.ObjectNotFoundError {
+				return nil
+			}
+			return err
+		}); diags != nil {
+			return diags
+		}
+		delete(records, k)
+	}
+
+	for _, k := range ids {
+		o, ok := records[k]
+		if !ok {
+			var obj policy.Policy
+			if diags := RetryWithBackoff(client, func() error {
+				var err error
+				obj, err = policy.Get(client, k)
+				return err
+			}); diags != nil {
+				return diags
+			}
+			o = policyStatusRecord{
+				Id:       obj.PolicyId,
+				Name:     obj.Name,
+				Enabled:  obj.Enabled,
+				Severity: obj.Severity,
+			}
+			records[k] = o
+		}
+
+		// Without an override, the original severity is put back, which
+		// also undoes an override that was just removed.
+		sev := severity
+		if sev == "" {
+			sev = o.Severity
+		}
+
+		if diags := RetryWithBackoff(client, func() error {
+			return setPolicyStatus(client, k, enabled, sev)
+		}); diags != nil {
+			return diags
+		}
+	}
+
+	return nil
+}
+
+func customizeDiffPolicyStatus(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// Policies matching the filter can come and go, so recheck the filter
+	// and plan an update if the matches changed.
+	if d.Id() == "" || len(d.Get("filter").([]interface{})) == 0 || !d.NewValueKnown("filter") {
+		return nil
+	}
+
+	client, ok := meta.(*pc.Client)
+	if !ok {
+		return nil
+	}
+
+	ids, err := policyStatusSelection(client, nil, d.Get("filter").([]interface{}))
+	if err != nil {
+		return err
+	}
+
+	current := make([]string, 0, len(ids))
+	for _, x := range d.Get("policies").([]interface{}) {
+		current = append(current, x.(map[string]interface{})["policy_id"].(string))
+	}
+	sort.Strings(current)
+
+	if fmt.Sprint(ids) != fmt.Sprint(current) {
+		return d.SetNewComputed("policies")
+	}
+
+	return nil
+}
+
+func createPolicyStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+
+	d.SetId(id.UniqueId())
+	if diags := applyPolicyStatus(client, d); diags != nil {
+		if len(loadPolicyStatusRecords(d)) == 0 {
+			d.SetId("")
+		}
+		return diags
+	}
+
+	return readPolicyStatus(ctx, d, meta)
+}
+
+func readPolicyStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	records := loadPolicyStatusRecords(d)
+	severity := d.Get("severity").(string)
+
+	enabled := d.Get("enabled").(bool)
+	sevOk := true
+	list := make([]policyStatusRecord, 0, len(records))
+	for _, o := range records {
+		var obj policy.Policy
+		var lastErr error
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			obj, err = policy.Get(client, o.Id)
+			lastErr = err
+			return err
+		}); diags != nil {
+			if lastErr == pc.ObjectNotFoundError {
+				continue
+			}
+			return diags
+		}
+
+		list = append(list, o)
+		if obj.Enabled != d.Get("enabled").(bool) {
+			enabled = obj.Enabled
+		}
+		if severity != "" && obj.Severity != severity {
+			sevOk = false
+		}
+	}
+
+	if len(list) == 0 {
+		d.SetId("")
+		return nil
+	}
+
+	// Any policy changed outside of Terraform shows up as a diff.
+	d.Set("enabled", enabled)
+	if !sevOk {
+		d.Set("severity", "")
+	}
+	savePolicyStatusRecords(d, list)
+
+	return nil
+}
+
+func updatePolicyStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+
+	if diags := applyPolicyStatus(client, d); diags != nil {
+		return diags
+	}
+
+	return readPolicyStatus(ctx, d, meta)
+}
+
+func deletePolicyStatus(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+
+	for _, o := range loadPolicyStatusRecords(d) {
+		if diags := RetryWithBackoff(client, func() error {
+			err := setPolicyStatus(client, o.Id, o.Enabled, o.Severity)
+			if err == pc.ObjectNotFoundError {
+				return nil
+			}
+			return err
+		}); diags != nil {
+			return diags
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,54 @@
+package prismacloud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestPriorPolicyStatusRecords(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "x",
+		Attributes: map[string]string{
+			"id":                           "x",
+			"enabled":                      "false",
+			"filter.#":                     "1",
+			"filter.0.cloud_type":          "aws",
+			"policies.#":                   "2",
+			"policies.0.policy_id":         "a",
+			"policies.0.name":              "Policy A",
+			"policies.0.original_enabled":  "true",
+			"policies.0.original_severity": "high",
+			"policies.1.policy_id":         "b",
+			"policies.1.name":              "Policy B",
+			"policies.1.original_enabled":  "false",
+			"policies.1.original_severity": "low",
+		},
+	}
+	want := []policyStatusRecord{
+		{Id: "a", Name: "Policy A", Enabled: true, Severity: "high"},
+		{Id: "b", Name: "Policy B", Enabled: false, Severity: "low"},
+	}
+
+	// A filter change plans "policies" as unknown.
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"filter.0.cloud_type": {Old: "aws", New: "gcp"},
+			"policies.#":          {Old: "2", NewComputed: true},
+		},
+	}
+
+	d, err := schema.InternalMap(resourcePolicyStatus().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("Error building the resource data: %s", err)
+	}
+
+	if got := loadPolicyStatusRecords(d); len(got) != 0 {
+		t.Errorf("Expected no planned records, got %#v", got)
+	}
+	if got := priorPolicyStatusRecords(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nexpected:\n%#v", got, want)
+	}
+}
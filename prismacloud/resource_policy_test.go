@@ -3,10 +3,13 @@ package prismacloud
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"testing"
 
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/history"
+	"github.com/paloaltonetworks/prisma-cloud-go/timerange"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -18,9 +21,9 @@ func TestAccPolicyConfig(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccPolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccPolicyDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccPolicyConfig(name, "config", "Config", "desc1", "aws", "low", map[string]string{"savedSearch": "true"}),
@@ -45,9 +48,9 @@ func TestAccPolicyNetwork(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccPolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccPolicyDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccPolicyConfig(name, "network", "Network", "desc1", "all", "low", map[string]string{"savedSearch": "true"}),
@@ -72,9 +75,9 @@ func TestAccPolicyAuditEvent(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccPolicyDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccPolicyDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccPolicyConfig(name, "audit_event", "AuditEvent", "desc1", "all", "low", map[string]string{"savedSearch": "true"}),
@@ -94,6 +97,118 @@ func TestAccPolicyAuditEvent(t *testing.T) {
 	})
 }
 
+func TestPolicySearchType(t *testing.T) {
+	cases := []struct {
+		ruleType   string
+		searchType string
+	}{
+		{policy.RuleTypeConfig, "config"},
+		{policy.RuleTypeAuditEvent, "event"},
+		{policy.RuleTypeNetwork, "network"},
+		{policy.RuleTypeIAM, "iam"},
+		{policy.RuleTypeNetworkConfig, "config"},
+		{"Anomaly", ""},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		got, err := policySearchType(tc.ruleType)
+		if tc.searchType == "" {
+			if err == nil {
+				t.Errorf("%q: got %q, expected an error", tc.ruleType, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.searchType {
+			t.Errorf("%q: got %q / %v, expected %q", tc.ruleType, got, err, tc.searchType)
+		}
+	}
+}
+
+func TestPolicySearchName(t *testing.T) {
+	query := "config from cloud.resource where cloud.type = 'aws'"
+	epoch := timerange.TimeRange{Type: timerange.TypeToNow, Value: timerange.Epoch}
+	week := timerange.TimeRange{Type: timerange.TypeRelative, Value: timerange.Relative{Amount: 1, Unit: timerange.Week}}
+
+	name := policySearchName("my policy", query, epoch)
+	if !regexp.MustCompile(`^my policy \([0-9a-f]{8}\)$`).MatchString(name) {
+		t.Errorf("Got name %q", name)
+	}
+	if again := policySearchName("my policy", query, epoch); again != name {
+		t.Errorf("Got %q, then %q for the same search", name, again)
+	}
+
+	// The name changes with the search, so the new search can be saved
+	// before the old one is deleted.
+	for _, other := range []string{
+		policySearchName("my policy", query+" and api.name = 'x'", epoch),
+		policySearchName("my policy", query, week),
+	} {
+		if other == name {
+			t.Errorf("Got %q for a different search", other)
+		}
+	}
+}
+
+func TestAccPolicyRql(t *testing.T) {
+	var o policy.Policy
+	var searchId string
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccPolicyDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPolicyRqlConfig(name, "config from cloud.resource where cloud.type = 'aws' AND api.name = 'aws-ec2-describe-instances'"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPolicyExists("prismacloud_policy.test", &o),
+					testAccCheckPolicySearch(&o, "", &searchId),
+				),
+			},
+			{
+				Config: testAccPolicyRqlConfig(name, "config from cloud.resource where cloud.type = 'aws' AND api.name = 'aws-s3api-get-bucket-acl'"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPolicyExists("prismacloud_policy.test", &o),
+					testAccCheckPolicySearch(&o, searchId, &searchId),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckPolicySearch checks that the policy uses a new saved search,
+// and that the previous one is gone.
+func testAccCheckPolicySearch(o *policy.Policy, prev string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs := s.RootModule().Resources["prismacloud_policy.test"]
+		cur := rs.Primary.Attributes["rule.0.search_id"]
+		if cur == "" {
+			return fmt.Errorf("Search ID is not set")
+		}
+		if o.Rule.Criteria != cur {
+			return fmt.Errorf("Criteria is %q, expected the saved search %q", o.Rule.Criteria, cur)
+		}
+
+		client := testAccProvider.Meta().(*pc.Client)
+		if _, err := history.Get(client, cur); err != nil {
+			return fmt.Errorf("Error getting saved search %q: %s", cur, err)
+		}
+		if prev != "" {
+			if cur == prev {
+				return fmt.Errorf("Search ID is still %q after changing rql", cur)
+			}
+			if _, err := history.Get(client, prev); err == nil {
+				return fmt.Errorf("Previous saved search %q still exists", prev)
+			}
+		}
+
+		*id = cur
+		return nil
+	}
+}
+
 func testAccCheckPolicyExists(n string, o *policy.Policy) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -220,3 +335,23 @@ resource "prismacloud_policy" "test" {
 
 	return buf.String()
 }
+
+func testAccPolicyRqlConfig(name, rql string) string {
+	return fmt.Sprintf(`
+resource "prismacloud_policy" "test" {
+    name = %q
+    policy_type = "config"
+    cloud_type = "aws"
+    severity = "low"
+    enabled = false
+    rule {
+        name = "my rule"
+        rule_type = "Config"
+        rql = %q
+        parameters = {
+            savedSearch: "true",
+        }
+    }
+}
+`, name, rql)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/paloaltonetworks/prisma-cloud-go/report"
 	"golang.org/x/net/context"
 	"log"
+	"time"
 )
 
 func resourceReport() *schema.Resource {
@@ -170,10 +171,12 @@ func resourceReport() *schema.Resource {
 							Description: "Report scheduling enabled",
 						},
 						"schedule": {
-							Type:        schema.TypeString,
-							Optional:    true,
-							Description: "Recurring report schedule in RRULE format",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  "Recurring report schedule in RRULE format",
+							ValidateFunc: validateRrule,
 						},
+						"next_runs": nextRunsSchema("the report is scheduled"),
 						"notification_template_id": {
 							Type:        schema.TypeString,
 							Optional:    true,
@@ -320,6 +323,7 @@ func saveReport(d *schema.ResourceData, obj report.Report, meta interface{}) {
 		"notify_to":                obj.Target.NotifyTo,
 		"resource_groups":          obj.Target.ResourceGroups,
 		"schedule":                 obj.Target.Schedule,
+		"next_runs":                rruleNextRunTimes(obj.Target.Schedule, "", 0, time.Now()),
 		"schedule_enabled":         obj.Target.ScheduleEnabled,
 		"notification_template_id": obj.Target.NotificationTemplateId,
 		"time_range":               tr,
@@ -348,18 +352,21 @@ func createReport(ctx context.Context, d *schema.ResourceData, meta interface{})
 	client := meta.(*pc.Client)
 	obj := parseReport(d, "")
 
-	if err := report.Create(client, obj); err != nil {
+	created, err := createReportObject(client, obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := report.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := report.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := report.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = report.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
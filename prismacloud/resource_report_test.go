@@ -17,9 +17,9 @@ func TestAccReportRIS(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccReportDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccReportDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccReportConfigRIS(name, "RIS", "aws"),
@@ -37,9 +37,9 @@ func TestAccReportInventory(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccReportDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccReportDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccReportConfigInventory(name, "INVENTORY_DETAIL", "aws", true, true, "DTSTART;TZID=Asia/Calcutta\nBYHOUR=8;BYMINUTE=0;BYSECOND=0;FREQ=WEEKLY;INTERVAL=1;BYDAY=WE"),
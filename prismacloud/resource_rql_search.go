@@ -1,23 +1,29 @@
 package prismacloud
 
 import (
+	"fmt"
+	"log"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"golang.org/x/net/context"
-	"log"
 
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
 	"github.com/paloaltonetworks/prisma-cloud-go/rql/search"
+	"github.com/paloaltonetworks/prisma-cloud-go/timerange"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceRqlSearch() *schema.Resource {
-	return &schema.Resource{
+	ans := &schema.Resource{
 		CreateContext: createUpdateRqlSearch,
 		ReadContext:   readRqlSearch,
 		UpdateContext: createUpdateRqlSearch,
 		DeleteContext: deleteRqlSearch,
+
+		CustomizeDiff: customizeDiffRqlSearchType,
+
 		Schema: map[string]*schema.Schema{
 			// Input.
 			"search_type": {
@@ -35,9 +41,10 @@ func resourceRqlSearch() *schema.Resource {
 				ForceNew: true,
 			},
 			"query": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The RQL search to perform",
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "The RQL search to perform",
+				ValidateFunc: validateRql,
 			},
 			"time_range": timeRangeSchema("resource_rql_search"),
 			"limit": {
@@ -485,6 +492,10 @@ func resourceRqlSearch() *schema.Resource {
 			},
 		},
 	}
+
+	addOutputFileSchema(ans.Schema)
+
+	return ans
 }
 
 func createUpdateRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -647,6 +658,11 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 	skipResult := d.Get("skip_result").(bool)
 	heuristicSearch := d.Get("heuristic_search").(bool)
 
+	if d.Get("output_file").(string) != "" {
+		return exportRqlSearch(d, client, searchType, searchId, query, tr, limit, heuristicSearch)
+	}
+	clearOutputFile(d)
+
 	switch searchType {
 	case "config":
 		req := search.ConfigRequest{
@@ -678,15 +694,7 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 		if len(resp.Data.Items) == 0 {
 			d.Set("config_data", nil)
 		} else {
-			list := make([]interface{}, 0, len(resp.Data.Items))
-			for _, x := range resp.Data.Items {
-				list = append(list, map[string]interface{}{
-					"state_id": x.StateId,
-					"name":     x.Name,
-					"url":      x.Url,
-				})
-			}
-
+			list := flattenRqlConfigItems(resp.Data.Items)
 			if err = d.Set("config_data", list); err != nil {
 				log.Printf("[WARN] Error setting 'config_data' for %q: %s", d.Id(), err)
 			}
@@ -720,15 +728,7 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 		if len(resp.Data.Items) == 0 {
 			d.Set("network_data", nil)
 		} else {
-			list := make([]interface{}, 0, len(resp.Data.Items))
-			for _, x := range resp.Data.Items {
-				list = append(list, map[string]interface{}{
-					"account":      x.Account,
-					"region_id":    x.RegionId,
-					"account_name": x.AccountName,
-				})
-			}
-
+			list := flattenRqlNetworkItems(resp.Data.Items)
 			if err = d.Set("network_data", list); err != nil {
 				log.Printf("[WARN] Error setting 'network_data' for %q: %s", d.Id(), err)
 			}
@@ -761,14 +761,7 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 		if len(resp.Data.Items) == 0 {
 			d.Set("event_data", nil)
 		} else {
-			list := make([]interface{}, 0, len(resp.Data.Items))
-			for _, x := range resp.Data.Items {
-				list = append(list, map[string]interface{}{
-					"account":               x.Account,
-					"region_id":             x.RegionId,
-					"region_api_identifier": x.RegionApiIdentifier,
-				})
-			}
+			list := flattenRqlEventItems(resp.Data.Items)
 			if err = d.Set("event_data", list); err != nil {
 				log.Printf("[WARN] Error setting 'event_data' for %q: %s", d.Id(), err)
 			}
@@ -801,59 +794,7 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 		if len(resp.Data.Items) == 0 {
 			d.Set("iam_data", nil)
 		} else {
-			list := make([]interface{}, 0, len(resp.Data.Items))
-			for _, x := range resp.Data.Items {
-				list = append(list, map[string]interface{}{
-					"accessed_resources_count":              x.AccessedResourcesCount,
-					"dest_cloud_account":                    x.DestCloudAccount,
-					"dest_cloud_region":                     x.DestCloudRegion,
-					"dest_cloud_resource_rrn":               x.DestCloudResourceRrn,
-					"dest_cloud_service_name":               x.DestCloudServiceName,
-					"dest_cloud_type":                       x.DestCloudType,
-					"dest_resource_id":                      x.DestResourceId,
-					"dest_resource_name":                    x.DestResourceName,
-					"dest_resource_type":                    x.DestResourceType,
-					"effective_action_name":                 x.EffectiveActionName,
-					"granted_by_cloud_entity_id":            x.GrantedByCloudEntityId,
-					"granted_by_cloud_entity_name":          x.GrantedByCloudEntityName,
-					"granted_by_cloud_entity_rrn":           x.GrantedByCloudEntityRrn,
-					"granted_by_cloud_entity_type":          x.GrantedByCloudEntityType,
-					"granted_by_cloud_policy_id":            x.GrantedByCloudPolicyId,
-					"granted_by_cloud_policy_name":          x.GrantedByCloudPolicyName,
-					"granted_by_cloud_policy_rrn":           x.GrantedByCloudPolicyRrn,
-					"granted_by_cloud_policy_type":          x.GrantedByCloudPolicyType,
-					"granted_by_cloud_type":                 x.GrantedByCloudType,
-					"message_id":                            x.MessageId,
-					"is_wild_card_dest_cloud_resource_name": x.IsWildCardDestCloudResourceName,
-					"last_access_date":                      x.LastAccessDate,
-					"source_cloud_account":                  x.SourceCloudAccount,
-					"source_cloud_region":                   x.SourceCloudRegion,
-					"source_cloud_resource_rrn":             x.SourceCloudResourceRrn,
-					"source_cloud_service_name":             x.SourceCloudServiceName,
-					"source_cloud_type":                     x.SourceCloudType,
-					"source_idp_domain":                     x.SourceIdpDomain,
-					"source_idp_email":                      x.SourceIdpEmail,
-					"source_idp_group":                      x.SourceIdpGroup,
-					"source_idp_rrn":                        x.SourceIdpRrn,
-					"source_idp_service":                    x.SourceIdpService,
-					"source_idp_user_name":                  x.SourceIdpRrn,
-					"source_public":                         x.SourcePublic,
-					"source_resource_id":                    x.SourceResourceId,
-					"source_resource_name":                  x.SourceResourceName,
-					"source_resource_type":                  x.SourceResourceType,
-				})
-
-				excList := make([]interface{}, 0, len(x.Exceptions))
-				for _, exc := range x.Exceptions {
-					excList = append(excList, map[string]interface{}{
-						"message_code": exc.MessageCode,
-					})
-				}
-				list = append(list, map[string]interface{}{
-					"exceptions": excList,
-				})
-			}
-
+			list := flattenRqlIamItems(resp.Data.Items)
 			if err = d.Set("iam_data", list); err != nil {
 				log.Printf("[WARN] Error setting 'iam_data' for %q: %s", d.Id(), err)
 			}
@@ -881,35 +822,7 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 		if len(resp.Value) == 0 {
 			d.Set("asset_data", nil)
 		} else {
-			list := make([]interface{}, 0, len(resp.Value))
-			for _, x := range resp.Value {
-				matchedSecurityIssuesList := make([]interface{}, 0, len(x.MatchedSecurityIssues))
-				for _, val := range x.MatchedSecurityIssues {
-					matchedSecurityIssuesList = append(matchedSecurityIssuesList, map[string]interface{}{
-						"type":  val.Type,
-						"count": val.Count,
-					})
-				}
-				list = append(list, map[string]interface{}{
-					"unified_asset_id":                x.UnifiedAssetId,
-					"external_asset_id":               x.ExternalAssetId,
-					"asset_name":                      x.AssetName,
-					"asset_type":                      x.AssetType,
-					"cloud_account_id":                x.CloudAccountId,
-					"cloud_account_name":              x.CloudAccountName,
-					"cloud_service_name":              x.CloudServiceName,
-					"cloud_region":                    x.CloudRegion,
-					"finding_count":                   x.FindingCount,
-					"last_modified_at":                x.LastModifiedAt,
-					"asset_category":                  x.AssetCategory,
-					"asset_class":                     x.AssetClass,
-					"cloud_type":                      x.CloudType,
-					"finding_types_by_severity_order": x.FindingTypesBySeverityOrder,
-					"matched_security_issues":         matchedSecurityIssuesList,
-					"total_security_issues_count":     x.TotalSecurityIssuesCount,
-					"matching_security_issues_count":  x.MatchingSecurityIssuesCount,
-				})
-			}
+			list := flattenRqlAssetItems(resp.Value)
 			log.Printf("Setting Asset Data")
 			if err = d.Set("asset_data", list); err != nil {
 				log.Printf("[WARN] Error setting 'asset_data' for %q: %s", d.Id(), err)
@@ -918,11 +831,222 @@ func readRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}
 	}
 	return nil
 }
+
+// exportRqlSearch reruns the search, writing up to limit results to the
+// output file instead of state.
+func exportRqlSearch(d *schema.ResourceData, client *pc.Client, searchType, searchId, query string, tr timerange.TimeRange, limit int, heuristicSearch bool) diag.Diagnostics {
+	res, diags := exportRqlResults(d, searchType, func(each func(interface{}) error) (rqlResults, error) {
+		return queryRql(client, searchType, searchId, query, tr, limit, heuristicSearch, each)
+	})
+	if diags.HasError() {
+		return diags
+	}
+
+	d.Set("search_id", res.Id)
+	d.Set("cloud_type", res.CloudType)
+	d.Set("name", res.Name)
+	d.Set("description", res.Description)
+	if err := d.Set("group_by", res.GroupBy); err != nil {
+		log.Printf("[WARN] Error setting 'group_by' for %q: %s", d.Id(), err)
+	}
+	for _, key := range rqlDataKeys {
+		d.Set(key, nil)
+	}
+
+	return nil
+}
+
+func flattenRqlConfigItems(items []search.ConfigItem) []interface{} {
+	list := make([]interface{}, 0, len(items))
+	for _, x := range items {
+		list = append(list, map[string]interface{}{
+			"state_id": x.StateId,
+			"name":     x.Name,
+			"url":      x.Url,
+		})
+	}
+
+	return list
+}
+
+func flattenRqlNetworkItems(items []search.NetworkItem) []interface{} {
+	list := make([]interface{}, 0, len(items))
+	for _, x := range items {
+		list = append(list, map[string]interface{}{
+			"account":      x.Account,
+			"region_id":    x.RegionId,
+			"account_name": x.AccountName,
+		})
+	}
+
+	return list
+}
+
+func flattenRqlEventItems(items []search.EventItem) []interface{} {
+	list := make([]interface{}, 0, len(items))
+	for _, x := range items {
+		list = append(list, map[string]interface{}{
+			"account":               x.Account,
+			"region_id":             x.RegionId,
+			"region_api_identifier": x.RegionApiIdentifier,
+		})
+	}
+	return list
+}
+
+func flattenRqlIamItems(items []search.IamItem) []interface{} {
+	list := make([]interface{}, 0, len(items))
+	for _, x := range items {
+		excList := make([]interface{}, 0, len(x.Exceptions))
+		for _, exc := range x.Exceptions {
+			excList = append(excList, map[string]interface{}{
+				"message_code": exc.MessageCode,
+			})
+		}
+
+		list = append(list, map[string]interface{}{
+			"accessed_resources_count":              x.AccessedResourcesCount,
+			"dest_cloud_account":                    x.DestCloudAccount,
+			"dest_cloud_region":                     x.DestCloudRegion,
+			"dest_cloud_resource_rrn":               x.DestCloudResourceRrn,
+			"dest_cloud_service_name":               x.DestCloudServiceName,
+			"dest_cloud_type":                       x.DestCloudType,
+			"dest_resource_id":                      x.DestResourceId,
+			"dest_resource_name":                    x.DestResourceName,
+			"dest_resource_type":                    x.DestResourceType,
+			"effective_action_name":                 x.EffectiveActionName,
+			"granted_by_cloud_entity_id":            x.GrantedByCloudEntityId,
+			"granted_by_cloud_entity_name":          x.GrantedByCloudEntityName,
+			"granted_by_cloud_entity_rrn":           x.GrantedByCloudEntityRrn,
+			"granted_by_cloud_entity_type":          x.GrantedByCloudEntityType,
+			"granted_by_cloud_policy_id":            x.GrantedByCloudPolicyId,
+			"granted_by_cloud_policy_name":          x.GrantedByCloudPolicyName,
+			"granted_by_cloud_policy_rrn":           x.GrantedByCloudPolicyRrn,
+			"granted_by_cloud_policy_type":          x.GrantedByCloudPolicyType,
+			"granted_by_cloud_type":                 x.GrantedByCloudType,
+			"message_id":                            x.MessageId,
+			"is_wild_card_dest_cloud_resource_name": x.IsWildCardDestCloudResourceName,
+			"last_access_date":                      x.LastAccessDate,
+			"source_cloud_account":                  x.SourceCloudAccount,
+			"source_cloud_region":                   x.SourceCloudRegion,
+			"source_cloud_resource_rrn":             x.SourceCloudResourceRrn,
+			"source_cloud_service_name":             x.SourceCloudServiceName,
+			"source_cloud_type":                     x.SourceCloudType,
+			"source_idp_domain":                     x.SourceIdpDomain,
+			"source_idp_email":                      x.SourceIdpEmail,
+			"source_idp_group":                      x.SourceIdpGroup,
+			"source_idp_rrn":                        x.SourceIdpRrn,
+			"source_idp_service":                    x.SourceIdpService,
+			"source_idp_user_name":                  x.SourceIdpUsername,
+			"source_public":                         x.SourcePublic,
+			"source_resource_id":                    x.SourceResourceId,
+			"source_resource_name":                  x.SourceResourceName,
+			"source_resource_type":                  x.SourceResourceType,
+			"exceptions":                            excList,
+		})
+	}
+
+	return list
+}
+
+func flattenRqlAssetItems(items []search.AssetValue) []interface{} {
+	list := make([]interface{}, 0, len(items))
+	for _, x := range items {
+		matchedSecurityIssuesList := make([]interface{}, 0, len(x.MatchedSecurityIssues))
+		for _, val := range x.MatchedSecurityIssues {
+			matchedSecurityIssuesList = append(matchedSecurityIssuesList, map[string]interface{}{
+				"type":  val.Type,
+				"count": val.Count,
+			})
+		}
+		list = append(list, map[string]interface{}{
+			"unified_asset_id":                x.UnifiedAssetId,
+			"external_asset_id":               x.ExternalAssetId,
+			"asset_name":                      x.AssetName,
+			"asset_type":                      x.AssetType,
+			"cloud_account_id":                x.CloudAccountId,
+			"cloud_account_name":              x.CloudAccountName,
+			"cloud_service_name":              x.CloudServiceName,
+			"cloud_region":                    x.CloudRegion,
+			"finding_count":                   x.FindingCount,
+			"last_modified_at":                x.LastModifiedAt,
+			"asset_category":                  x.AssetCategory,
+			"asset_class":                     x.AssetClass,
+			"cloud_type":                      x.CloudType,
+			"finding_types_by_severity_order": x.FindingTypesBySeverityOrder,
+			"matched_security_issues":         matchedSecurityIssuesList,
+			"total_security_issues_count":     x.TotalSecurityIssuesCount,
+			"matching_security_issues_count":  x.MatchingSecurityIssuesCount,
+		})
+	}
+	return list
+}
+
 func deleteRqlSearch(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	// There is no way to delete a search, so this is a no-op.
 	return nil
 }
 
+// rqlTimeRange parses a time_range param, which defaults to all time.
+func rqlTimeRange(v interface{}) timerange.TimeRange {
+	if trl, ok := v.([]interface{}); ok && len(trl) != 0 && trl[0] != nil {
+		if tr := ParseTimeRange(trl[0].(map[string]interface{})); tr.Value != nil {
+			return tr
+		}
+	}
+
+	return timerange.TimeRange{
+		Type:  timerange.TypeToNow,
+		Value: timerange.Epoch,
+	}
+}
+
+// runRqlSearch performs the given search without fetching its results and
+// returns the search ID, which is what history.Save needs.
+func runRqlSearch(client *pc.Client, searchType, query string, tr timerange.TimeRange) (string, error) {
+	switch searchType {
+	case "config":
+		resp, err := search.ConfigSearch(client, search.ConfigRequest{
+			Query:      query,
+			Limit:      1,
+			TimeRange:  tr,
+			SkipResult: true,
+		})
+		return resp.Id, err
+	case "network":
+		resp, err := search.NetworkSearch(client, search.NetworkRequest{
+			Query:      query,
+			Limit:      1,
+			TimeRange:  tr,
+			SkipResult: true,
+		})
+		return resp.Id, err
+	case "event":
+		resp, err := search.EventSearch(client, search.EventRequest{
+			Query:      query,
+			Limit:      1,
+			TimeRange:  tr,
+			SkipResult: true,
+		})
+		return resp.Id, err
+	case "iam":
+		resp, err := search.IamSearch(client, search.IamRequest{
+			Query: query,
+			Limit: 1,
+		})
+		return resp.Id, err
+	case "asset":
+		resp, err := search.AssetSearch(client, search.AssetRequest{
+			Query:      query,
+			Limit:      1,
+			SkipResult: true,
+		})
+		return resp.ResultMetadata.SearchId, err
+	}
+
+	return "", fmt.Errorf("unknown search type %q", searchType)
+}
+
 // Id functions.
 func buildRqlSearchId(a, b, c string) string {
 	res := Base64Encode([]interface{}{a, b, c})
@@ -0,0 +1,60 @@
+package prismacloud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/search"
+)
+
+func TestFlattenRqlIamItems(t *testing.T) {
+	items := []search.IamItem{
+		{
+			MessageId:         "m-1",
+			SourceIdpRrn:      "rrn::idp:okta:user/alice",
+			SourceIdpUsername: "alice",
+			Exceptions: []search.Exception{
+				{MessageCode: "a"},
+				{MessageCode: "b"},
+			},
+		},
+		{
+			MessageId:         "m-2",
+			SourceIdpUsername: "bob",
+		},
+	}
+
+	list := flattenRqlIamItems(items)
+	if len(list) != len(items) {
+		t.Fatalf("Got %d items, expected %d", len(list), len(items))
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceRqlSearch().Schema, map[string]interface{}{})
+	if err := d.Set("iam_data", list); err != nil {
+		t.Fatalf("Error setting iam_data: %s", err)
+	}
+
+	for i, want := range []struct {
+		messageId  string
+		userName   string
+		exceptions []interface{}
+	}{
+		{"m-1", "alice", []interface{}{
+			map[string]interface{}{"message_code": "a"},
+			map[string]interface{}{"message_code": "b"},
+		}},
+		{"m-2", "bob", []interface{}{}},
+	} {
+		item := d.Get("iam_data").([]interface{})[i].(map[string]interface{})
+		if item["message_id"] != want.messageId {
+			t.Errorf("Item %d: message_id is %q, expected %q", i, item["message_id"], want.messageId)
+		}
+		if item["source_idp_user_name"] != want.userName {
+			t.Errorf("Item %d: source_idp_user_name is %q, expected %q", i, item["source_idp_user_name"], want.userName)
+		}
+		if !reflect.DeepEqual(item["exceptions"], want.exceptions) {
+			t.Errorf("Item %d: exceptions are %#v, expected %#v", i, item["exceptions"], want.exceptions)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package prismacloud
 
 import (
+	"fmt"
+	"log"
+	"strings"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
 	"github.com/paloaltonetworks/prisma-cloud-go/rql/history"
 	"golang.org/x/net/context"
 
@@ -23,18 +28,22 @@ func resourceSavedSearch() *schema.Resource {
 		Schema: map[string]*schema.Schema{
 			// Input.
 			"query": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The RQL search to perform",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "The RQL search to perform",
+				ValidateFunc: validateRql,
 			},
 			"search_id": {
 				Type:        schema.TypeString,
 				Required:    true,
+				ForceNew:    true,
 				Description: "The RQL UUID",
 			},
 			"name": {
 				Type:        schema.TypeString,
 				Required:    true,
+				ForceNew:    true,
 				Description: "Saved search name",
 			},
 			"description": {
@@ -70,21 +79,22 @@ func resourceSavedSearch() *schema.Resource {
 	}
 }
 
-func createSavedSearch(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*pc.Client)
-
-	req := history.SavedSearch{
-		Id:          d.Get("search_id").(string),
+func parseSavedSearch(d *schema.ResourceData, id string) history.SavedSearch {
+	return history.SavedSearch{
+		Id:          id,
 		Name:        d.Get("name").(string),
 		Query:       d.Get("query").(string),
 		Description: d.Get("description").(string),
 		TimeRange:   ParseTimeRange(ResourceDataInterfaceMap(d, "time_range")),
 		CloudType:   d.Get("cloud_type").(string),
 	}
+}
 
+// saveSearch saves (or re-saves) a search, waiting until it can be read back.
+func saveSearch(client *pc.Client, req history.SavedSearch) (string, error) {
 	resp, err := history.Save(client, req)
 	if err != nil {
-		return diag.FromErr(err)
+		return "", err
 	}
 
 	var resp1 history.Query
@@ -94,40 +104,83 @@ func createSavedSearch(ctx context.Context, d *schema.ResourceData, meta interfa
 		return err
 	})
 
-	d.SetId(resp1.Id)
+	return resp1.Id, nil
+}
 
-	return readSavedSearch(ctx, d, meta)
+// policiesUsingSavedSearch returns the custom policies whose rule criteria is
+// the given saved search.
+func policiesUsingSavedSearch(client *pc.Client, id string) ([]policy.Policy, error) {
+	var list []policy.Policy
+	var lastErr error
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		list, err = policy.List(client, map[string]string{"policy.policyMode": "custom"})
+		lastErr = err
+		return err
+	}); diags != nil {
+		return nil, lastErr
+	}
+
+	var ans []policy.Policy
+	for _, p := range list {
+		if criteria, ok := p.Rule.Criteria.(string); ok && criteria == id {
+			ans = append(ans, p)
+		}
+	}
+
+	return ans, nil
 }
 
-func updateSavedSearch(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+func createSavedSearch(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*pc.Client)
-	old, new := d.GetChange("name")
-	if old.(string) != new.(string) {
-		return diag.Errorf("saved search name is immutable")
+	searchId := d.Get("search_id").(string)
+
+	// A search can only be saved once, so when replacing a saved search (such
+	// as a rename with create_before_destroy), the query is run again to get a
+	// search of its own.
+	var info history.Query
+	var lastErr error
+	if diags := RetryWithBackoff(client, func() error {
+		var err error
+		info, err = history.Get(client, searchId)
+		lastErr = err
+		return err
+	}); diags != nil && lastErr != pc.ObjectNotFoundError {
+		return diags
 	}
-
-	req := history.SavedSearch{
-		Id:          d.Get("search_id").(string),
-		Name:        d.Get("name").(string),
-		Query:       d.Get("query").(string),
-		Description: d.Get("description").(string),
-		TimeRange:   ParseTimeRange(ResourceDataInterfaceMap(d, "time_range")),
-		CloudType:   d.Get("cloud_type").(string),
+	if lastErr == nil && info.Saved {
+		q, err := parseRqlHead(d.Get("query").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		tr := ParseTimeRange(ResourceDataInterfaceMap(d, "time_range"))
+		if diags := RetryWithBackoff(client, func() error {
+			var err error
+			searchId, err = runRqlSearch(client, q.searchType(), d.Get("query").(string), tr)
+			return err
+		}); diags != nil {
+			return diags
+		}
+		log.Printf("[DEBUG] Search %q is already saved as %q, saving new search %q", d.Get("search_id").(string), info.Name, searchId)
 	}
 
-	resp, err := history.Save(client, req)
+	id, err := saveSearch(client, parseSavedSearch(d, searchId))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	var resp1 history.Query
-	PollApiUntilSuccess(func() error {
-		resp2, err := history.Get(client, resp.Id)
-		resp1 = resp2
-		return err
-	})
+	d.SetId(id)
+
+	return readSavedSearch(ctx, d, meta)
+}
+
+func updateSavedSearch(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
 
-	d.SetId(resp1.Id)
+	if _, err := saveSearch(client, parseSavedSearch(d, d.Id())); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return readSavedSearch(ctx, d, meta)
 }
@@ -138,11 +191,19 @@ func readSavedSearch(ctx context.Context, d *schema.ResourceData, meta interface
 
 	info, err := history.Get(client, id)
 	if err != nil {
+		if err == pc.ObjectNotFoundError {
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(err)
 	}
 
+	// The search_id is the search that was asked to be saved, which is not
+	// the saved search's ID if the search had to be run again.
+	if d.Get("search_id").(string) == "" {
+		d.Set("search_id", info.Id)
+	}
 	d.Set("query", info.Query)
-	d.Set("search_id", info.Id)
 	d.Set("name", info.Name)
 	d.Set("description", info.Description)
 	d.Set("saved", info.Saved)
@@ -155,6 +216,32 @@ func deleteSavedSearch(ctx context.Context, d *schema.ResourceData, meta interfa
 	client := meta.(*pc.Client)
 	id := d.Id()
 
-	_ = history.Delete(client, id)
+	users, err := policiesUsingSavedSearch(client, id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(users) != 0 {
+		names := make([]string, 0, len(users))
+		for _, p := range users {
+			names = append(names, fmt.Sprintf("%q (%s)", p.Name, p.PolicyId))
+		}
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Saved search %q is still used by %d custom policies", d.Get("name").(string), len(users)),
+			Detail: fmt.Sprintf(
+				"Deleting this saved search would leave the following policies without rule criteria: %s.\n\n"+
+					"Point their rule criteria at another saved search first.  If this saved search is being replaced, "+
+					"reference it from the policies by its \"id\" and set create_before_destroy in its lifecycle, so that "+
+					"the policies are updated before it is deleted.",
+				strings.Join(names, ", "),
+			),
+		}}
+	}
+
+	if err := history.Delete(client, id); err != nil && err != pc.ObjectNotFoundError {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
 	return nil
 }
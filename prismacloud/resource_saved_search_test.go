@@ -0,0 +1,130 @@
+package prismacloud
+
+import (
+	"fmt"
+	"testing"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"github.com/paloaltonetworks/prisma-cloud-go/rql/history"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccSavedSearchRenameInUse(t *testing.T) {
+	var o policy.Policy
+	var first string
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccPolicyDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSavedSearchInUseConfig(name, name),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPolicyExists("prismacloud_policy.test", &o),
+					testAccCheckSavedSearchInUse(&o, name, "", &first),
+				),
+			},
+			{
+				// The new saved search is created, and the policy moved to
+				// it, before the old one is destroyed.
+				Config: testAccSavedSearchInUseConfig(name, name+" renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPolicyExists("prismacloud_policy.test", &o),
+					testAccCheckSavedSearchInUse(&o, name+" renamed", first, &first),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckSavedSearchInUse checks that the policy uses the saved search
+// of the given name, and that the previous saved search is gone.
+func testAccCheckSavedSearchInUse(o *policy.Policy, name, prev string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources["prismacloud_saved_search.test"]
+		if !ok {
+			return fmt.Errorf("Resource not found: prismacloud_saved_search.test")
+		}
+		cur := rs.Primary.ID
+
+		if o.Rule.Criteria != cur {
+			return fmt.Errorf("Policy criteria is %v, expected the saved search %q", o.Rule.Criteria, cur)
+		}
+
+		client := testAccProvider.Meta().(*pc.Client)
+		q, err := history.Get(client, cur)
+		if err != nil {
+			return fmt.Errorf("Error getting saved search %q: %s", cur, err)
+		}
+		if q.Name != name {
+			return fmt.Errorf("Saved search name is %q, expected %q", q.Name, name)
+		}
+
+		if prev != "" {
+			if cur == prev {
+				return fmt.Errorf("Saved search is still %q after the rename", cur)
+			}
+			if _, err := history.Get(client, prev); err == nil {
+				return fmt.Errorf("Previous saved search %q still exists", prev)
+			}
+		}
+
+		*id = cur
+		return nil
+	}
+}
+
+func testAccSavedSearchInUseConfig(policyName, searchName string) string {
+	return fmt.Sprintf(`
+resource "prismacloud_rql_search" "test" {
+    search_type = "config"
+    skip_result = true
+    query = "config from cloud.resource where api.name = 'aws-ec2-describe-instances'"
+    time_range {
+        relative {
+            unit = "hour"
+            amount = 24
+        }
+    }
+}
+
+resource "prismacloud_saved_search" "test" {
+    name = %q
+    description = "made by terraform"
+    search_id = prismacloud_rql_search.test.search_id
+    query = prismacloud_rql_search.test.query
+    time_range {
+        relative {
+            unit = "hour"
+            amount = 24
+        }
+    }
+
+    lifecycle {
+        create_before_destroy = true
+    }
+}
+
+resource "prismacloud_policy" "test" {
+    name = %q
+    policy_type = "config"
+    cloud_type = "aws"
+    severity = "low"
+    enabled = false
+    rule {
+        name = "my rule"
+        rule_type = "Config"
+        criteria = prismacloud_saved_search.test.id
+        parameters = {
+            savedSearch: "true",
+        }
+    }
+}
+`, searchName, policyName)
+}
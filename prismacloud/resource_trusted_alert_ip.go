@@ -117,19 +117,25 @@ func createTrustedAlertIp(ctx context.Context, d *schema.ResourceData, meta inte
 	obj := parseTrustedAlertIp(d, "")
 
 	var id string
-	var id1 string
-	id1, _ = trustedalertip.Identify(client, obj.Name)
-	if id1 == "" {
-		if _, err := trustedalertip.Create(client, obj); err != nil {
+	id, _ = trustedalertip.Identify(client, obj.Name)
+	if id == "" {
+		body, err := trustedalertip.Create(client, obj)
+		if err != nil {
 			return diag.FromErr(err)
 		}
+
+		var created trustedalertip.TrustedAlertIP
+		decodeCreated(strings.Join(trustedalertip.Suffix, "/"), body, &created)
+		id = created.UUID
 	}
 
-	PollApiUntilSuccess(func() error {
-		id2, err := trustedalertip.Identify(client, obj.Name)
-		id = id2
-		return err
-	})
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			id2, err := trustedalertip.Identify(client, obj.Name)
+			id = id2
+			return err
+		})
+	}
 	for _, o := range obj.CIDRS {
 		_, err := trustedalertip.CreateCIDR(client, o, id)
 		if err == pc.OverlappingCIDRError {
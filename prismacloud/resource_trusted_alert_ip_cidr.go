@@ -0,0 +1,260 @@
+package prismacloud
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"golang.org/x/net/context"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/trusted-alert-ip"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceTrustedAlertIpCidr() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createTrustedAlertIpCidr,
+		ReadContext:   readTrustedAlertIpCidr,
+		UpdateContext: updateTrustedAlertIpCidr,
+		DeleteContext: deleteTrustedAlertIpCidr,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importTrustedAlertIpCidr,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"trusted_alert_ip_uuid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "UUID of the trusted alert IP list this CIDR belongs to",
+			},
+			"cidr": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "CIDR",
+				ValidateFunc: validation.IsCIDR,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return sameCidr(old, new)
+				},
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description",
+			},
+			"uuid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "CIDR UUID",
+			},
+			"created_on": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Created on",
+			},
+		},
+	}
+}
+
+func importTrustedAlertIpCidr(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if len(strings.Split(d.Id(), IdSeparator)) != 2 {
+		return nil, fmt.Errorf("Expected an ID of the form <list uuid>%s<cidr uuid>, got %q", IdSeparator, d.Id())
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func parseTrustedAlertIpCidr(d *schema.ResourceData, uuid string) trustedalertip.CIDRS {
+	return trustedalertip.CIDRS{
+		UUID:        uuid,
+		CIDR:        d.Get("cidr").(string),
+		Description: d.Get("description").(string),
+	}
+}
+
+func saveTrustedAlertIpCidr(d *schema.ResourceData, listUuid string, obj trustedalertip.CIDRS) {
+	d.Set("trusted_alert_ip_uuid", listUuid)
+	d.Set("cidr", obj.CIDR)
+	d.Set("description", obj.Description)
+	d.Set("uuid", obj.UUID)
+	d.Set("created_on", obj.CreatedOn)
+}
+
+// canonicalCidr returns the network of a CIDR, so that "10.0.0.5/24" and
+// "10.0.0.0/24" compare equal.  Anything that does not parse is returned as
+// is.
+func canonicalCidr(s string) string {
+	_, ipnet, err := net.ParseCIDR(strings.TrimSpace(s))
+	if err != nil {
+		return s
+	}
+
+	return ipnet.String()
+}
+
+func sameCidr(a, b string) bool {
+	return canonicalCidr(a) == canonicalCidr(b)
+}
+
+// findTrustedAlertIpCidr returns the entry of the given list that matches
+// the given CIDR UUID, or, if uuid is empty, the given CIDR.
+func findTrustedAlertIpCidr(client *pc.Client, listUuid, uuid, cidr string) (trustedalertip.CIDRS, error) {
+	list, err := trustedalertip.Get(client, listUuid)
+	if err != nil {
+		return trustedalertip.CIDRS{}, err
+	}
+
+	for _, o := range list.CIDRS {
+		if (uuid != "" && o.UUID == uuid) || (uuid == "" && sameCidr(o.CIDR, cidr)) {
+			return o, nil
+		}
+	}
+
+	return trustedalertip.CIDRS{}, pc.ObjectNotFoundError
+}
+
+// isOverlappingCidrError reports whether err means the CIDR clashes with an
+// existing entry.
+func isOverlappingCidrError(err error) bool {
+	return errors.Is(err, pc.OverlappingCIDRError)
+}
+
+// overlappingCidrDiagnostics turns an OverlappingCIDRError into a diagnostic
+// that names the entries the CIDR clashes with.
+func overlappingCidrDiagnostics(client *pc.Client, cidr string) diag.Diagnostics {
+	var clashes []string
+
+	if _, ipnet, err := net.ParseCIDR(cidr); err == nil {
+		if lists, err := trustedalertip.List(client); err == nil {
+			for _, list := range lists {
+				for _, o := range list.CIDRS {
+					_, other, err := net.ParseCIDR(o.CIDR)
+					if err != nil {
+						continue
+					}
+					if ipnet.Contains(other.IP) || other.Contains(ipnet.IP) {
+						clashes = append(clashes, fmt.Sprintf("%s (list %q, cidr uuid %s)", o.CIDR, list.Name, o.UUID))
+					}
+				}
+			}
+		}
+	}
+
+	detail := fmt.Sprintf("Prisma Cloud rejected %s because it overlaps a CIDR that is already trusted.", cidr)
+	if len(clashes) != 0 {
+		detail += "  Overlapping entries: " + strings.Join(clashes, ", ") + "."
+	}
+	detail += "  Remove or narrow one of the ranges, or import the existing entry instead."
+
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       "CIDR overlaps an existing trusted alert IP",
+		Detail:        detail,
+		AttributePath: cty.GetAttrPath("cidr"),
+	}}
+}
+
+func createTrustedAlertIpCidr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	listUuid := d.Get("trusted_alert_ip_uuid").(string)
+	obj := parseTrustedAlertIpCidr(d, "")
+
+	objectLocks.Lock("trusted_alert_ip/" + listUuid)
+	defer objectLocks.Unlock("trusted_alert_ip/" + listUuid)
+
+	body, err := trustedalertip.CreateCIDR(client, obj, listUuid)
+	if err != nil {
+		if isOverlappingCidrError(err) {
+			return overlappingCidrDiagnostics(client, obj.CIDR)
+		}
+		return diag.FromErr(err)
+	}
+
+	path := make([]string, 0, len(trustedalertip.Suffix)+2)
+	path = append(path, trustedalertip.Suffix...)
+	path = append(path, listUuid, "cidr")
+
+	var created trustedalertip.CIDRS
+	decodeCreated(strings.Join(path, "/"), body, &created)
+
+	// The list may hold the CIDR in canonical form, so it is matched by
+	// network, not by string.
+	if created.UUID == "" {
+		err = PollApiUntilSuccessContext(ctx, func() error {
+			var err error
+			created, err = findTrustedAlertIpCidr(client, listUuid, "", obj.CIDR)
+			return err
+		})
+		if err != nil {
+			return diag.Errorf("Error finding the new CIDR %s in trusted alert IP %q: %s", obj.CIDR, listUuid, err)
+		}
+	}
+
+	d.SetId(TwoStringsToId(listUuid, created.UUID))
+	return readTrustedAlertIpCidr(ctx, d, meta)
+}
+
+func readTrustedAlertIpCidr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	listUuid, uuid := IdToTwoStrings(d.Id())
+
+	obj, err := findTrustedAlertIpCidr(client, listUuid, uuid, "")
+	if err != nil {
+		if err == pc.ObjectNotFoundError {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	saveTrustedAlertIpCidr(d, listUuid, obj)
+
+	return nil
+}
+
+func updateTrustedAlertIpCidr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	listUuid, uuid := IdToTwoStrings(d.Id())
+	obj := parseTrustedAlertIpCidr(d, uuid)
+
+	objectLocks.Lock("trusted_alert_ip/" + listUuid)
+	defer objectLocks.Unlock("trusted_alert_ip/" + listUuid)
+
+	if _, err := trustedalertip.UpdateCIDR(client, obj, listUuid, uuid); err != nil {
+		if isOverlappingCidrError(err) {
+			return overlappingCidrDiagnostics(client, obj.CIDR)
+		}
+		return diag.FromErr(err)
+	}
+
+	return readTrustedAlertIpCidr(ctx, d, meta)
+}
+
+func deleteTrustedAlertIpCidr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*pc.Client)
+	listUuid, uuid := IdToTwoStrings(d.Id())
+
+	objectLocks.Lock("trusted_alert_ip/" + listUuid)
+	defer objectLocks.Unlock("trusted_alert_ip/" + listUuid)
+
+	if _, err := trustedalertip.DeleteCIDRFromTrustedAlertIp(client, listUuid, uuid); err != nil {
+		if err != pc.ObjectNotFoundError {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,122 @@
+package prismacloud
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"golang.org/x/net/context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestSameCidr(t *testing.T) {
+	cases := []struct {
+		a, b string
+		same bool
+	}{
+		{"10.0.0.0/24", "10.0.0.0/24", true},
+		{"10.0.0.5/24", "10.0.0.0/24", true},
+		{" 10.0.0.5/24", "10.0.0.0/24", true},
+		{"10.0.0.0/24", "10.0.1.0/24", false},
+		{"10.0.0.0/24", "10.0.0.0/25", false},
+		{"2001:db8::1/64", "2001:db8::/64", true},
+		{"bogus", "bogus", true},
+		{"bogus", "10.0.0.0/24", false},
+	}
+
+	for _, tc := range cases {
+		if got := sameCidr(tc.a, tc.b); got != tc.same {
+			t.Errorf("sameCidr(%q, %q) is %t, expected %t", tc.a, tc.b, got, tc.same)
+		}
+	}
+}
+
+func TestIsOverlappingCidrError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{pc.OverlappingCIDRError, true},
+		{fmt.Errorf("wrapped: %w", pc.OverlappingCIDRError), true},
+		{fmt.Errorf("405 error without the x-redlock-status header - returned HTML:\n"), false},
+		{pc.ObjectNotFoundError, false},
+	}
+
+	for _, tc := range cases {
+		if got := isOverlappingCidrError(tc.err); got != tc.want {
+			t.Errorf("isOverlappingCidrError(%q) is %t, expected %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestPollApiUntilSuccessContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := PollApiUntilSuccessContext(ctx, func() error {
+		calls++
+		return fmt.Errorf("not yet")
+	})
+	if err == nil {
+		t.Fatalf("Expected an error once the context is done")
+	}
+	if calls != 1 {
+		t.Errorf("Polled %d times, expected 1", calls)
+	}
+
+	calls = 0
+	err = PollApiUntilSuccessContext(context.Background(), func() error {
+		calls++
+		return nil
+	})
+	if err != nil || calls != 1 {
+		t.Errorf("Got %v after %d polls, expected success after 1", err, calls)
+	}
+}
+
+func TestAccTrustedAlertIpCidr(t *testing.T) {
+	name := fmt.Sprintf("tf%s", acctest.RandString(6))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// A host address is stored as its network.
+				Config: testAccTrustedAlertIpCidrConfig(name, "10.11.12.5/24", "first desc"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("prismacloud_trusted_alert_ip_cidr.test", "uuid"),
+					resource.TestCheckResourceAttr("prismacloud_trusted_alert_ip_cidr.test", "description", "first desc"),
+				),
+			},
+			{
+				Config: testAccTrustedAlertIpCidrConfig(name, "10.11.12.0/24", "second desc"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("prismacloud_trusted_alert_ip_cidr.test", "description", "second desc"),
+				),
+			},
+		},
+	})
+}
+
+func testAccTrustedAlertIpCidrConfig(name, cidr, desc string) string {
+	return fmt.Sprintf(`
+resource "prismacloud_trusted_alert_ip" "test" {
+    name = %q
+
+    lifecycle {
+        ignore_changes = [cidrs]
+    }
+}
+
+resource "prismacloud_trusted_alert_ip_cidr" "test" {
+    trusted_alert_ip_uuid = prismacloud_trusted_alert_ip.test.uuid
+    cidr = %q
+    description = %q
+}
+`, name, cidr, desc)
+}
@@ -55,18 +55,21 @@ func createTrustedLoginIp(ctx context.Context, d *schema.ResourceData, meta inte
 	client := meta.(*pc.Client)
 	obj := parseTrustedLoginIp(d, "")
 
-	if err := ip_address.Create(client, obj); err != nil {
+	created, err := createTrustedLoginIpObject(client, obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := ip_address.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := ip_address.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := ip_address.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = ip_address.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -17,9 +17,9 @@ func TestAccUser(t *testing.T) {
 	secondname := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccUserProfileDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccUserProfileDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccUserProfileConfig(firstname, secondname, "test@paloaltonetworks.com", "Test user Profile", "Asia/Calcutta"),
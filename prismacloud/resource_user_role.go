@@ -231,18 +231,21 @@ func createUserRole(ctx context.Context, d *schema.ResourceData, meta interface{
 	client := meta.(*pc.Client)
 	obj := parseUserRole(d)
 
-	if err := role.Create(client, *obj); err != nil {
+	created, err := createUserRoleObject(client, *obj)
+	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	PollApiUntilSuccess(func() error {
-		_, err := role.Identify(client, obj.Name)
-		return err
-	})
+	id := created.Id
+	if id == "" {
+		PollApiUntilSuccess(func() error {
+			_, err := role.Identify(client, obj.Name)
+			return err
+		})
 
-	id, err := role.Identify(client, obj.Name)
-	if err != nil {
-		return diag.FromErr(err)
+		if id, err = role.Identify(client, obj.Name); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	PollApiUntilSuccess(func() error {
@@ -17,9 +17,9 @@ func TestAccUserRole(t *testing.T) {
 	name := fmt.Sprintf("tf%s", acctest.RandString(6))
 
 	resource.Test(t, resource.TestCase{
-		PreCheck:     func() { testAccPreCheck(t) },
-		Providers:    testAccProviders,
-		CheckDestroy: testAccUserRoleDestroy,
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccUserRoleDestroy,
 		Steps: []resource.TestStep{
 			{
 				Config: testAccUserRoleConfig(name, "first desc", "Account Group Read Only"),
@@ -0,0 +1,827 @@
+package prismacloud
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+)
+
+/*
+This is an offline linter for RQL, so that obvious mistakes are reported at
+plan time instead of by the API halfway through an apply.
+
+It understands the following query shapes:
+
+	config from cloud.resource where <conditions> [addcolumn ...] [as X]
+	config from iam where <conditions>
+	config from network where <conditions>
+	event from cloud.audit_logs where <conditions>
+	network from vpc.flow_record where <conditions>
+	asset where <conditions>
+
+Config queries may be joined with ";" followed by "filter '...'",
+"show X" and "count(X) <comparison> <number>".  The legacy "config where",
+"event where" and "network where" forms (without a from clause) are accepted
+as well.
+
+The linter is deliberately lenient about what it does not understand: field
+names are not checked, and a json.rule expression only has to have balanced
+brackets.  RQL has more to it than this, so only a bad "<kind> from <source>"
+head is an error; anything else the linter rejects is reported as a warning.
+*/
+
+const (
+	rqlEOF = iota
+	rqlWord
+	rqlString
+	rqlNumber
+	rqlOperator
+	rqlLParen
+	rqlRParen
+	rqlComma
+	rqlSemicolon
+	rqlOther
+)
+
+type rqlToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// rqlError is a syntax error at a given byte offset of the query.
+type rqlError struct {
+	query string
+	pos   int
+	msg   string
+}
+
+func (e *rqlError) Error() string {
+	return fmt.Sprintf("RQL syntax error at column %d: %s", utf8.RuneCountInString(e.query[:e.pos])+1, e.msg)
+}
+
+// rqlQuery is what the linter learned about a query.
+type rqlQuery struct {
+	// kind is the leading keyword: config, event, network or asset.
+	kind string
+	// source is the from clause, or an empty string if there is none.
+	source string
+	// aliases are the "as X" names of joined queries.
+	aliases []string
+}
+
+// searchType returns the prismacloud_rql_search search_type the query needs.
+func (q *rqlQuery) searchType() string {
+	if q.kind == "config" && q.source == "iam" {
+		return "iam"
+	}
+	return q.kind
+}
+
+// rqlSources are the valid from clauses of each query kind.
+var rqlSources = map[string][]string{
+	"config":  {"cloud.resource", "iam", "network"},
+	"event":   {"cloud.audit_logs"},
+	"network": {"vpc.flow_record"},
+}
+
+// rqlReserved may not be used as a field name or a bare value.
+var rqlReserved = []string{"where", "from", "and", "or", "as", "addcolumn", "filter", "show", "in"}
+
+func lexRql(s string) ([]rqlToken, error) {
+	var ans []rqlToken
+
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '\'' || r == '"':
+			start := i
+			i += size
+			closed := false
+			for i < len(s) {
+				if s[i] == '\\' {
+					i += 2
+					continue
+				}
+				if rune(s[i]) == r {
+					closed = true
+					i++
+					break
+				}
+				i++
+			}
+			if !closed {
+				return nil, &rqlError{s, start, "unterminated string"}
+			}
+			ans = append(ans, rqlToken{rqlString, s[start:i], start})
+		case r >= '0' && r <= '9' || r == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			start := i
+			i++
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			ans = append(ans, rqlToken{rqlNumber, s[start:i], start})
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(s) {
+				r, size = utf8.DecodeRuneInString(s[i:])
+				if !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-$", r)) {
+					break
+				}
+				i += size
+			}
+			ans = append(ans, rqlToken{rqlWord, s[start:i], start})
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			i++
+			if i < len(s) && stringInSlice(s[start:i+1], []string{"==", "!=", "<>", "<=", ">="}) {
+				i++
+			}
+			if s[start:i] == "!" {
+				ans = append(ans, rqlToken{rqlOther, "!", start})
+			} else {
+				ans = append(ans, rqlToken{rqlOperator, s[start:i], start})
+			}
+		case r == '(':
+			ans = append(ans, rqlToken{rqlLParen, "(", i})
+			i++
+		case r == ')':
+			ans = append(ans, rqlToken{rqlRParen, ")", i})
+			i++
+		case r == ',':
+			ans = append(ans, rqlToken{rqlComma, ",", i})
+			i++
+		case r == ';':
+			ans = append(ans, rqlToken{rqlSemicolon, ";", i})
+			i++
+		default:
+			ans = append(ans, rqlToken{rqlOther, s[i : i+size], i})
+			i += size
+		}
+	}
+
+	ans = append(ans, rqlToken{rqlEOF, "", len(s)})
+	return ans, nil
+}
+
+type rqlParser struct {
+	query  string
+	tokens []rqlToken
+	i      int
+}
+
+func (p *rqlParser) peek() rqlToken {
+	return p.tokens[p.i]
+}
+
+func (p *rqlParser) peekAt(n int) rqlToken {
+	if p.i+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.i+n]
+}
+
+func (p *rqlParser) next() rqlToken {
+	t := p.tokens[p.i]
+	if t.kind != rqlEOF {
+		p.i++
+	}
+	return t
+}
+
+// isWord reports whether the next token is one of the given keywords.
+func (p *rqlParser) isWord(words ...string) bool {
+	return tokenIsWord(p.peek(), words...)
+}
+
+func tokenIsWord(t rqlToken, words ...string) bool {
+	if t.kind != rqlWord {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *rqlParser) errorf(t rqlToken, format string, a ...interface{}) error {
+	return &rqlError{p.query, t.pos, fmt.Sprintf(format, a...)}
+}
+
+func describeRqlToken(t rqlToken) string {
+	if t.kind == rqlEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// parseRqlHead parses only the leading "<kind> from <source>" of the query,
+// which is all that is needed to tell what kind of query it is.
+func parseRqlHead(s string) (*rqlQuery, error) {
+	tokens, err := lexRql(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &rqlParser{query: s, tokens: tokens}
+	if p.peek().kind == rqlEOF {
+		return nil, p.errorf(p.peek(), "query is empty")
+	}
+
+	return p.parseHead()
+}
+
+// parseRql lints the given query.
+func parseRql(s string) (*rqlQuery, error) {
+	tokens, err := lexRql(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &rqlParser{query: s, tokens: tokens}
+	if p.peek().kind == rqlEOF {
+		return nil, p.errorf(p.peek(), "query is empty")
+	}
+
+	var ans *rqlQuery
+	for {
+		q, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		if ans == nil {
+			ans = q
+		} else if q.kind != ans.kind || q.source != ans.source {
+			return nil, p.errorf(p.peek(), "joined queries must all be %s queries", ans.describe())
+		} else if len(q.aliases) != 0 {
+			ans.aliases = append(ans.aliases, q.aliases[0])
+		}
+
+		if p.peek().kind != rqlSemicolon {
+			break
+		}
+		p.next()
+		if p.peek().kind == rqlEOF || p.isWord("filter", "show") || p.isCount() {
+			break
+		}
+	}
+
+	if err := p.parseJoinTail(ans); err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != rqlEOF {
+		if t.kind == rqlRParen {
+			return nil, p.errorf(t, "unbalanced \")\"")
+		}
+		return nil, p.errorf(t, "unexpected %s", describeRqlToken(t))
+	}
+
+	return ans, nil
+}
+
+func (q *rqlQuery) describe() string {
+	if q.source == "" {
+		return q.kind
+	}
+	return q.kind + " from " + q.source
+}
+
+func (p *rqlParser) parseHead() (*rqlQuery, error) {
+	t := p.next()
+	if !tokenIsWord(t, "config", "event", "network", "asset") {
+		if tokenIsWord(t, "where", "from", "filter", "show", "addcolumn") {
+			return nil, p.errorf(t, "a query must start with config, event, network or asset, not %s", strings.ToLower(t.text))
+		}
+		return nil, p.errorf(t, "expected config, event, network or asset, found %s", describeRqlToken(t))
+	}
+	q := &rqlQuery{kind: strings.ToLower(t.text)}
+
+	if p.isWord("from") {
+		from := p.next()
+		if q.kind == "asset" {
+			return nil, p.errorf(from, "asset queries do not have a from clause")
+		}
+		src := p.next()
+		if src.kind != rqlWord || tokenIsWord(src, rqlReserved...) {
+			return nil, p.errorf(src, "expected a data source after from, found %s", describeRqlToken(src))
+		}
+		q.source = strings.ToLower(src.text)
+		if !stringInSlice(q.source, rqlSources[q.kind]) {
+			return nil, p.errorf(src, "unknown data source %q for %s queries, expected one of: %s", src.text, q.kind, strings.Join(rqlSources[q.kind], ", "))
+		}
+	}
+
+	return q, nil
+}
+
+func (p *rqlParser) parseStatement() (*rqlQuery, error) {
+	q, err := p.parseHead()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	switch {
+	case tokenIsWord(t, "where"):
+		p.next()
+	case tokenIsWord(t, "addcolumn", "as"):
+		return nil, p.errorf(t, "the where clause must come before %s", strings.ToLower(t.text))
+	case tokenIsWord(t, "from"):
+		return nil, p.errorf(t, "duplicate from clause")
+	default:
+		return nil, p.errorf(t, "expected where, found %s", describeRqlToken(t))
+	}
+
+	if err := p.parseOr(); err != nil {
+		return nil, err
+	}
+
+	var seenAddcolumn, seenAs bool
+	for {
+		t = p.peek()
+		switch {
+		case tokenIsWord(t, "addcolumn"):
+			if seenAddcolumn {
+				return nil, p.errorf(t, "duplicate addcolumn clause")
+			}
+			seenAddcolumn = true
+			p.next()
+			n := 0
+			for p.peek().kind == rqlWord && !p.isWord(rqlReserved...) {
+				p.next()
+				n++
+			}
+			if n == 0 {
+				return nil, p.errorf(p.peek(), "addcolumn needs at least one column, found %s", describeRqlToken(p.peek()))
+			}
+		case tokenIsWord(t, "as"):
+			if seenAs {
+				return nil, p.errorf(t, "duplicate as clause")
+			}
+			seenAs = true
+			p.next()
+			alias := p.next()
+			if alias.kind != rqlWord || tokenIsWord(alias, rqlReserved...) {
+				return nil, p.errorf(alias, "expected an alias after as, found %s", describeRqlToken(alias))
+			}
+			q.aliases = append(q.aliases, alias.text)
+		case tokenIsWord(t, "where"):
+			return nil, p.errorf(t, "duplicate where clause")
+		case tokenIsWord(t, "from"):
+			return nil, p.errorf(t, "the from clause must come before where")
+		default:
+			return q, nil
+		}
+	}
+}
+
+// isCount reports whether the next token starts a "count(X)" clause.
+func (p *rqlParser) isCount() bool {
+	return p.isWord("count") && p.peekAt(1).kind == rqlLParen
+}
+
+// parseJoinTail parses the "filter '...'; show X; count(X) ..." part of
+// joined queries.
+func (p *rqlParser) parseJoinTail(q *rqlQuery) error {
+	var seenFilter, seenShow, seenCount bool
+
+	for p.isWord("filter", "show") || p.isCount() {
+		t := p.next()
+		if seenCount {
+			return p.errorf(t, "count must come last")
+		}
+		switch strings.ToLower(t.text) {
+		case "filter":
+			if seenShow {
+				return p.errorf(t, "filter must come before show")
+			}
+			if seenFilter {
+				return p.errorf(t, "duplicate filter clause")
+			}
+			seenFilter = true
+			if len(q.aliases) == 0 {
+				return p.errorf(t, "filter needs the queries to be named with as")
+			}
+			if v := p.next(); v.kind != rqlString {
+				return p.errorf(v, "expected a quoted expression after filter, found %s", describeRqlToken(v))
+			}
+		case "show":
+			if seenShow {
+				return p.errorf(t, "duplicate show clause")
+			}
+			seenShow = true
+			v := p.next()
+			if v.kind != rqlWord {
+				return p.errorf(v, "expected an alias after show, found %s", describeRqlToken(v))
+			}
+			if !stringInSlice(v.text, q.aliases) {
+				return p.errorf(v, "show refers to %q, which is not the alias of any query", v.text)
+			}
+		case "count":
+			seenCount = true
+			if err := p.parseCount(q); err != nil {
+				return err
+			}
+		}
+
+		if p.peek().kind == rqlSemicolon {
+			p.next()
+		}
+	}
+
+	if len(q.aliases) > 1 && !seenShow {
+		return p.errorf(p.peek(), "joined queries need a show clause")
+	}
+
+	return nil
+}
+
+// parseCount parses the "(X) <comparison> <number>" after count, where the
+// comparison is an operator or words such as "less than".
+func (p *rqlParser) parseCount(q *rqlQuery) error {
+	p.next()
+	v := p.next()
+	if v.kind != rqlWord {
+		return p.errorf(v, "expected an alias in count, found %s", describeRqlToken(v))
+	}
+	if !stringInSlice(v.text, q.aliases) {
+		return p.errorf(v, "count refers to %q, which is not the alias of any query", v.text)
+	}
+	if c := p.next(); c.kind != rqlRParen {
+		return p.errorf(c, "expected \")\" after the count alias, found %s", describeRqlToken(c))
+	}
+
+	op := p.next()
+	if op.kind != rqlOperator {
+		if !tokenIsWord(op, "less", "greater", "equal", "equals", "not") {
+			return p.errorf(op, "expected a comparison after count, found %s", describeRqlToken(op))
+		}
+		for p.isWord("than", "to", "or", "equal", "equals") {
+			p.next()
+		}
+	}
+	if n := p.next(); n.kind != rqlNumber {
+		return p.errorf(n, "expected a number after the count comparison, found %s", describeRqlToken(n))
+	}
+
+	return nil
+}
+
+func (p *rqlParser) parseOr() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.isWord("or") {
+		p.next()
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rqlParser) parseAnd() error {
+	if err := p.parseUnary(); err != nil {
+		return err
+	}
+	for p.isWord("and") {
+		p.next()
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rqlParser) parseUnary() error {
+	if p.isWord("not") {
+		p.next()
+		return p.parseUnary()
+	}
+
+	if t := p.peek(); t.kind == rqlLParen {
+		p.next()
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+		if c := p.next(); c.kind != rqlRParen {
+			if c.kind == rqlEOF {
+				return p.errorf(t, "unbalanced \"(\"")
+			}
+			return p.errorf(c, "expected and, or or \")\", found %s", describeRqlToken(c))
+		}
+		return nil
+	}
+
+	return p.parseCondition()
+}
+
+func (p *rqlParser) parseCondition() error {
+	field := p.next()
+	switch {
+	case field.kind == rqlEOF:
+		return p.errorf(field, "expected a condition, found end of query")
+	case field.kind == rqlWord && tokenIsWord(field, rqlReserved...):
+		return p.errorf(field, "expected a condition, found %s", strings.ToLower(field.text))
+	case field.kind != rqlWord:
+		return p.errorf(field, "expected a field name, found %s", describeRqlToken(field))
+	}
+
+	if strings.EqualFold(field.text, "json.rule") {
+		if op := p.next(); op.kind != rqlOperator || op.text != "=" {
+			return p.errorf(op, "expected = after json.rule, found %s", describeRqlToken(op))
+		}
+		return p.parseJsonRule()
+	}
+
+	// Functions such as grantedby.cloud.policy.condition('aws:sourceIP').
+	if p.peek().kind == rqlLParen {
+		if err := p.parseValueList(); err != nil {
+			return err
+		}
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == rqlOperator:
+		return p.parseValue(op)
+	case tokenIsWord(op, "in"):
+		return p.parseValueList()
+	case tokenIsWord(op, "not"):
+		if p.isWord("in") {
+			p.next()
+			return p.parseValueList()
+		}
+		if p.isWord("contains") {
+			p.next()
+			return p.parseContains(op)
+		}
+		return p.errorf(p.peek(), "expected in or contains after not, found %s", describeRqlToken(p.peek()))
+	case tokenIsWord(op, "contains"):
+		return p.parseContains(op)
+	case tokenIsWord(op, "does"):
+		if p.isWord("not") {
+			p.next()
+			if p.isWord("exist", "exists") {
+				p.next()
+				return nil
+			}
+			if p.isWord("contain", "contains") {
+				p.next()
+				return p.parseContains(op)
+			}
+		}
+		return p.errorf(p.peek(), "expected not exist or not contain after does, found %s", describeRqlToken(p.peek()))
+	case tokenIsWord(op, "exists", "exist"):
+		// "ip exists in network.list_name" checks a trusted IP list.
+		if p.isWord("in") {
+			in := p.next()
+			return p.parseValue(in)
+		}
+		return nil
+	case tokenIsWord(op, "is"):
+		if p.isWord("not") {
+			p.next()
+		}
+		switch {
+		case p.isWord("empty", "true", "false"):
+			p.next()
+			return nil
+		case p.isWord("member"):
+			p.next()
+			if !p.isWord("of") {
+				return p.errorf(p.peek(), "expected of after is member, found %s", describeRqlToken(p.peek()))
+			}
+			p.next()
+			return p.parseValueList()
+		}
+		return p.errorf(p.peek(), "expected empty, true, false or member of after is, found %s", describeRqlToken(p.peek()))
+	case tokenIsWord(op, "starts", "ends"):
+		if !p.isWord("with") {
+			return p.errorf(p.peek(), "expected with after %s, found %s", strings.ToLower(op.text), describeRqlToken(p.peek()))
+		}
+		p.next()
+		return p.parseValue(op)
+	case tokenIsWord(op, "like", "intersects"):
+		return p.parseValue(op)
+	}
+
+	return p.errorf(op, "expected an operator after %s, found %s", field.text, describeRqlToken(op))
+}
+
+func (p *rqlParser) parseContains(op rqlToken) error {
+	if p.isWord("any", "all") {
+		p.next()
+	}
+	if p.peek().kind == rqlLParen {
+		return p.parseValueList()
+	}
+	return p.parseValue(op)
+}
+
+func (p *rqlParser) parseValue(op rqlToken) error {
+	v := p.next()
+	switch {
+	case v.kind == rqlString || v.kind == rqlNumber:
+		return nil
+	case v.kind == rqlOther && v.text == "*":
+		return nil
+	case v.kind == rqlWord && !tokenIsWord(v, rqlReserved...):
+		return nil
+	case v.kind == rqlLParen:
+		p.i--
+		return p.parseValueList()
+	}
+
+	return p.errorf(v, "expected a value after %s, found %s", strings.ToLower(op.text), describeRqlToken(v))
+}
+
+// parseValueList parses "( v1, v2, ... )" or a "( resource where ... )"
+// subquery.
+func (p *rqlParser) parseValueList() error {
+	open := p.next()
+	if open.kind != rqlLParen {
+		return p.errorf(open, "expected a parenthesized list, found %s", describeRqlToken(open))
+	}
+
+	if p.isWord("resource") && tokenIsWord(p.peekAt(1), "where") {
+		p.next()
+		p.next()
+		if err := p.parseOr(); err != nil {
+			return err
+		}
+	} else {
+		for {
+			if err := p.parseValue(open); err != nil {
+				return err
+			}
+			if p.peek().kind != rqlComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if c := p.next(); c.kind != rqlRParen {
+		if c.kind == rqlEOF {
+			return p.errorf(open, "unbalanced \"(\"")
+		}
+		return p.errorf(c, "expected \",\" or \")\" in list, found %s", describeRqlToken(c))
+	}
+
+	return nil
+}
+
+// parseJsonRule skips over a json.rule expression, which runs to the end
+// of the where clause, only checking that its brackets are balanced.
+func (p *rqlParser) parseJsonRule() error {
+	start := p.peek()
+	var stack []rqlToken
+	n := 0
+
+	closers := map[string]string{"(": ")", "[": "]", "{": "}"}
+
+	for {
+		t := p.peek()
+		if len(stack) == 0 {
+			if t.kind == rqlEOF || t.kind == rqlSemicolon || t.kind == rqlRParen || tokenIsWord(t, "addcolumn") {
+				break
+			}
+			if tokenIsWord(t, "as") && p.peekAt(1).kind == rqlWord {
+				if k := p.peekAt(2).kind; k == rqlEOF || k == rqlSemicolon {
+					break
+				}
+			}
+		}
+		if t.kind == rqlEOF {
+			open := stack[len(stack)-1]
+			return p.errorf(open, "unbalanced %q in json.rule", open.text)
+		}
+
+		switch {
+		case t.kind == rqlLParen || t.text == "[" || t.text == "{":
+			stack = append(stack, t)
+		case t.kind == rqlRParen || t.text == "]" || t.text == "}":
+			if len(stack) == 0 {
+				return p.errorf(t, "unbalanced %q in json.rule", t.text)
+			}
+			if open := stack[len(stack)-1]; closers[open.text] != t.text {
+				return p.errorf(t, "%q does not match %q in json.rule", t.text, open.text)
+			}
+			stack = stack[:len(stack)-1]
+		}
+		p.next()
+		n++
+	}
+
+	if n == 0 {
+		return p.errorf(start, "json.rule needs an expression")
+	}
+	return nil
+}
+
+// looksLikeRql reports whether s is an RQL query as opposed to a saved
+// search ID or a JSON document.
+func looksLikeRql(s string) bool {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return false
+	}
+	return stringInSlice(strings.ToLower(fields[0]), []string{"config", "event", "network", "asset"})
+}
+
+// validateRql is a ValidateFunc for params that hold an RQL query.
+func validateRql(v interface{}, k string) ([]string, []error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected %q to be a string", k)}
+	}
+
+	if _, err := parseRqlHead(s); err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", k, err)}
+	}
+
+	// The linter does not know all of RQL, so the API has the final say on
+	// the rest of the query.
+	if _, err := parseRql(s); err != nil {
+		return []string{fmt.Sprintf("%s may not be valid RQL: %s", k, err)}, nil
+	}
+
+	return nil, nil
+}
+
+// validateRqlCriteria is a ValidateFunc for params that hold either an RQL
+// query or something else, such as a saved search ID.
+func validateRqlCriteria(v interface{}, k string) ([]string, []error) {
+	if s, ok := v.(string); !ok || !looksLikeRql(s) {
+		return nil, nil
+	}
+	return validateRql(v, k)
+}
+
+// checkRqlSearchType returns an error if the query cannot be run as the
+// given search type.  Queries that fail to parse are left to validateRql.
+func checkRqlSearchType(query, searchType string) error {
+	q, err := parseRqlHead(query)
+	if err != nil {
+		return nil
+	}
+
+	if q.searchType() != searchType {
+		return fmt.Errorf("search_type is %q, but %q is a %s query; set search_type to %q", searchType, q.describe(), q.searchType(), q.searchType())
+	}
+
+	return nil
+}
+
+// rqlRuleTypes maps a policy's rule_type to the kind of RQL it takes.
+var rqlRuleTypes = map[string]string{
+	policy.RuleTypeConfig:        "config",
+	policy.RuleTypeAuditEvent:    "event",
+	policy.RuleTypeNetwork:       "network",
+	policy.RuleTypeIAM:           "iam",
+	policy.RuleTypeNetworkConfig: "config",
+}
+
+// checkRqlRuleType returns an error if the query does not fit the rule type.
+func checkRqlRuleType(query, ruleType string) error {
+	want, ok := rqlRuleTypes[ruleType]
+	if !ok || !looksLikeRql(query) {
+		return nil
+	}
+
+	q, err := parseRqlHead(query)
+	if err != nil {
+		return nil
+	}
+
+	if q.searchType() != want {
+		return fmt.Errorf("rule_type %q needs a %s query, but %q is a %s query", ruleType, want, q.describe(), q.searchType())
+	}
+	if ruleType == policy.RuleTypeNetworkConfig && q.source != "network" {
+		return fmt.Errorf("rule_type %q needs a config from network query", ruleType)
+	}
+
+	return nil
+}
+
+// customizeDiffRqlSearchType checks that "query" matches "search_type".
+func customizeDiffRqlSearchType(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("query") || !d.NewValueKnown("search_type") {
+		return nil
+	}
+
+	return checkRqlSearchType(d.Get("query").(string), d.Get("search_type").(string))
+}
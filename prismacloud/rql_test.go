@@ -0,0 +1,307 @@
+package prismacloud
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRqlValid(t *testing.T) {
+	cases := []struct {
+		query      string
+		searchType string
+	}{
+		// Config.
+		{`config from cloud.resource where cloud.type = 'aws'`, "config"},
+		{`config from cloud.resource where cloud.type = 'aws' AND api.name = 'aws-ec2-describe-instances'`, "config"},
+		{`CONFIG FROM cloud.resource WHERE api.name = "aws-s3api-get-bucket-acl"`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' AND json.rule = publicIpAddress exists`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-security-groups' AND json.rule = ipPermissions[*].ipRanges[*] contains 0.0.0.0/0 and ipPermissions[*].toPort == 22`, "config"},
+		{`config from cloud.resource where api.name = 'aws-iam-get-account-password-policy' AND json.rule = 'requireUppercaseCharacters is false'`, "config"},
+		{`config from cloud.resource where cloud.type = 'azure' AND api.name = 'azure-storage-account-list' AND json.rule = properties.networkAcls.defaultAction equal ignore case Allow`, "config"},
+		{`config from cloud.resource where api.name = 'gcloud-compute-instances-list' AND json.rule = (status equals RUNNING and name does not start with "gke-") and networkInterfaces[*].accessConfigs exists`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-vpcs' AND json.rule = tags[?any(key == "Name")] exists`, "config"},
+		{`config from cloud.resource where api.name = 'aws-s3api-get-bucket-acl' AND json.rule = policy.Statement[?(@.Effect=='Allow')].Principal contains *`, "config"},
+		{`config from cloud.resource where cloud.account IN ('Production', 'Staging') AND resource.status = Active`, "config"},
+		{`config from cloud.resource where cloud.region NOT IN ('AWS Ohio')`, "config"},
+		{`config from cloud.resource where cloud.type = 'aws' and (api.name = 'aws-ec2-describe-instances' or api.name = 'aws-lambda-list-functions')`, "config"},
+		{`config from cloud.resource where NOT (cloud.type = 'aws')`, "config"},
+		{`config from cloud.resource where finding.type IN ('Host Vulnerability', 'Compliance') AND finding.severity = 'high'`, "config"},
+		{`config from cloud.resource where cloud.account = * AND api.name = 'aws-ec2-describe-instances'`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' addcolumn tags publicIpAddress`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' AND json.rule = state.name = running addcolumn tags`, "config"},
+		{`config from cloud.resource where resource.status = Deleted AND cloud.service = 'Amazon EC2'`, "config"},
+		{`config from cloud.resource where azure.resource.group = 'rg-1' and cloud.type = 'azure'`, "config"},
+		{`config from cloud.resource where api.name = 'aws-rds-describe-db-instances' AND json.rule = allocatedStorage > 100`, "config"},
+		{`config from cloud.resource where api.name = 'aws-rds-describe-db-instances' AND json.rule = backupRetentionPeriod < 7 or backupRetentionPeriod >= -1`, "config"},
+		{`config from cloud.resource where tag.key = 'env' and tag.value != 'prod'`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' AND json.rule = tags[*].key is member of ("Name", "Owner")`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' AND json.rule = keyName is not empty`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' AND json.rule = keyName does not exist`, "config"},
+		{`config from cloud.resource where api.name starts with 'aws-ec2'`, "config"},
+		{`config from cloud.resource where api.name ends with 'instances'`, "config"},
+		{`config from cloud.resource where api.name like 'aws-%'`, "config"},
+		{`config from cloud.resource where cloud.account contains 'prod'`, "config"},
+		{`config from cloud.resource where cloud.account does not contain 'test'`, "config"},
+		{`config from cloud.resource where api.name = 'aws-s3api-get-bucket-acl';`, "config"},
+		{"config from cloud.resource\nwhere cloud.type = 'aws'\n  AND api.name = 'aws-ec2-describe-instances'\n", "config"},
+		{`config where api.name = 'aws-ec2-describe-instances'`, "config"},
+
+		// Joins.
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' as X; config from cloud.resource where api.name = 'aws-ec2-describe-security-groups' as Y; filter '$.X.securityGroups[*].groupId contains $.Y.groupId'; show X;`, "config"},
+		{`config from cloud.resource where api.name = 'aws-ec2-describe-instances' AND json.rule = state.name = running as X; config from cloud.resource where api.name = 'aws-iam-list-roles' as Y; filter '$.X.iamInstanceProfile.arn equals $.Y.arn'; show Y`, "config"},
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y; config from cloud.resource where api.name = 'c' as Z; filter '$.X.id == $.Y.id and $.Y.id == $.Z.id'; show Z;`, "config"},
+		{`config from cloud.resource where api.name = 'x' as X; count(X) less than 1`, "config"},
+		{`config from cloud.resource where api.name = 'x' as X; show X; count(X) less than 1`, "config"},
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y; filter '$.X.id == $.Y.id'; show X; count(X) greater than or equal to 2;`, "config"},
+		{`config from cloud.resource where api.name = 'x' as X; count(X) >= 1`, "config"},
+
+		// Network config.
+		{`config from network where source.network = INTERNET and dest.resource.type = 'Instance' and dest.cloud.type = 'AWS'`, "config"},
+		{`config from network where source.network = '0.0.0.0/0' and address.match.criteria = 'full_match' and dest.resource.type = 'Interface' and protocol.ports in ( 'tcp/22', 'tcp/3389' )`, "config"},
+
+		// IAM.
+		{`config from iam where dest.cloud.type = 'AWS' AND grantedby.cloud.policy.type = 'AWS Managed Policy'`, "iam"},
+		{`config from iam where source.cloud.service.name = 'ec2' and dest.cloud.resource.name = '*'`, "iam"},
+		{`config from iam where action.name CONTAINS 's3:' and dest.cloud.type = 'AWS'`, "iam"},
+		{`config from iam where source.public = true`, "iam"},
+		{`config from iam where grantedby.cloud.policy.condition ( 'aws:sourceIP' ) does not exist`, "iam"},
+		{`config from iam where dest.cloud.wildcardscope = true and action.name IN ('iam:PassRole', 'sts:AssumeRole')`, "iam"},
+
+		// Event.
+		{`event from cloud.audit_logs where operation IN ('DeleteBucket', 'PutBucketPolicy')`, "event"},
+		{`event from cloud.audit_logs where cloud.type = 'aws' AND user = 'root'`, "event"},
+		{`event from cloud.audit_logs where operation = 'ConsoleLogin' AND json.rule = $.responseElements.ConsoleLogin = "Failure"`, "event"},
+		{`event from cloud.audit_logs where crud = 'delete' and ip.address != '10.0.0.1'`, "event"},
+		{`event from cloud.audit_logs where alert.profile = 'foo' and anomaly.type = 'UEBA'`, "event"},
+		{`event from cloud.audit_logs where ip EXISTS IN network.list_name`, "event"},
+		{`event from cloud.audit_logs where operation = 'ConsoleLogin' and ip exists in network.trusted_ips`, "event"},
+		{`event where operation = 'DeleteTrail'`, "event"},
+
+		// Network.
+		{`network from vpc.flow_record where cloud.account = 'Production' AND source.publicnetwork IN ('Internet IPs', 'Suspicious IPs') AND bytes > 0`, "network"},
+		{`network from vpc.flow_record where dest.port IN (22, 3389) and protocol NOT IN ('ICMP')`, "network"},
+		{`network from vpc.flow_record where source.ip = 10.0.0.1 and (bytes > 0 OR packets > 0)`, "network"},
+		{`network from vpc.flow_record where dest.resource IN ( resource where role IN ( 'AWS NAT Gateway', 'AWS ELB' ) ) and bytes > 0`, "network"},
+		{`network from vpc.flow_record where source.isanomaly = true and dest.country = 'China'`, "network"},
+		{`network where source.publicnetwork IN ('Internet IPs')`, "network"},
+
+		// Asset.
+		{`asset where asset.class = 'Compute'`, "asset"},
+		{`asset where cloud.type = 'AWS' and asset.type = 'EC2 Instance'`, "asset"},
+		{`asset where asset.severity IN ('high', 'critical') OR vulnerability.count > 10`, "asset"},
+		{`asset where not (cloud.region = 'AWS Ohio')`, "asset"},
+	}
+
+	for _, tc := range cases {
+		q, err := parseRql(tc.query)
+		if err != nil {
+			t.Errorf("%s: %s", tc.query, err)
+			continue
+		}
+		if got := q.searchType(); got != tc.searchType {
+			t.Errorf("%s: search type is %q, expected %q", tc.query, got, tc.searchType)
+		}
+	}
+}
+
+func TestParseRqlInvalid(t *testing.T) {
+	cases := []struct {
+		query string
+		err   string
+	}{
+		{``, "query is empty"},
+		{`   `, "query is empty"},
+		{`select * from cloud.resource`, `expected config, event, network or asset, found "select"`},
+		{`where cloud.type = 'aws'`, "must start with config, event, network or asset, not where"},
+		{`from cloud.resource where cloud.type = 'aws'`, "not from"},
+
+		// From clause.
+		{`config from cloud.resources where cloud.type = 'aws'`, `unknown data source "cloud.resources" for config queries`},
+		{`event from cloud.audit_log where user = 'root'`, `unknown data source "cloud.audit_log" for event queries`},
+		{`network from cloud.resource where bytes > 0`, `unknown data source "cloud.resource" for network queries`},
+		{`config from where cloud.type = 'aws'`, "expected a data source after from"},
+		{`asset from cloud.resource where cloud.type = 'aws'`, "asset queries do not have a from clause"},
+		{`config from cloud.resource from iam where cloud.type = 'aws'`, "duplicate from clause"},
+
+		// Clause order.
+		{`config from cloud.resource`, "expected where, found end of query"},
+		{`config from cloud.resource cloud.type = 'aws'`, `expected where, found "cloud.type"`},
+		{`config from cloud.resource addcolumn tags where cloud.type = 'aws'`, "the where clause must come before addcolumn"},
+		{`config from cloud.resource as X where cloud.type = 'aws'`, "the where clause must come before as"},
+		{`config where cloud.type = 'aws' from cloud.resource`, "the from clause must come before where"},
+		{`config from cloud.resource where cloud.type = 'aws' where api.name = 'x'`, "duplicate where clause"},
+		{`config from cloud.resource where cloud.type = 'aws' addcolumn`, "addcolumn needs at least one column"},
+		{`config from cloud.resource where cloud.type = 'aws' addcolumn a addcolumn b`, "duplicate addcolumn clause"},
+		{`config from cloud.resource where cloud.type = 'aws' as`, "expected an alias after as"},
+
+		// Conditions.
+		{`config from cloud.resource where`, "expected a condition, found end of query"},
+		{`config from cloud.resource where cloud.type = 'aws' AND`, "expected a condition, found end of query"},
+		{`config from cloud.resource where cloud.type = 'aws' AND AND api.name = 'x'`, "expected a condition, found and"},
+		{`config from cloud.resource where OR cloud.type = 'aws'`, "expected a condition, found or"},
+		{`config from cloud.resource where 'aws' = cloud.type`, "expected a field name"},
+		{`config from cloud.resource where cloud.type`, "expected an operator after cloud.type, found end of query"},
+		{`config from cloud.resource where cloud.type 'aws'`, `expected an operator after cloud.type, found "'aws'"`},
+		{`config from cloud.resource where cloud.type =`, "expected a value after =, found end of query"},
+		{`config from cloud.resource where cloud.type = AND api.name = 'x'`, `expected a value after =, found "AND"`},
+		{`config from cloud.resource where cloud.type = 'aws' api.name = 'x'`, `unexpected "api.name"`},
+		{`config from cloud.resource where cloud.type ! 'aws'`, `expected an operator after cloud.type, found "!"`},
+		{`config from cloud.resource where cloud.region IN 'AWS Ohio'`, "expected a parenthesized list"},
+		{`config from cloud.resource where cloud.region IN ('a' 'b')`, `expected "," or ")" in list`},
+		{`config from cloud.resource where cloud.region IN ('a',)`, `expected a value after (, found ")"`},
+		{`config from cloud.resource where cloud.region IN ('a', 'b'`, `unbalanced "("`},
+		{`config from cloud.resource where cloud.region NOT ('a')`, "expected in or contains after not"},
+		{`config from cloud.resource where cloud.account does exist`, "expected not exist or not contain after does"},
+		{`config from cloud.resource where cloud.account is null`, "expected empty, true, false or member of after is"},
+		{`config from cloud.resource where cloud.account is member ('a')`, "expected of after is member"},
+		{`config from cloud.resource where api.name starts 'aws'`, "expected with after starts"},
+		{`config from cloud.resource where (cloud.type = 'aws'`, `unbalanced "("`},
+		{`config from cloud.resource where cloud.type = 'aws')`, `unbalanced ")"`},
+		{`config from cloud.resource where (cloud.type = 'aws' api.name = 'x')`, `expected and, or or ")", found "api.name"`},
+		{`config from cloud.resource where cloud.type = 'aws`, "unterminated string"},
+		{`config from cloud.resource where cloud.type = "aws'`, "unterminated string"},
+
+		// json.rule.
+		{`config from cloud.resource where api.name = 'x' AND json.rule`, "expected = after json.rule"},
+		{`config from cloud.resource where api.name = 'x' AND json.rule = `, "json.rule needs an expression"},
+		{`config from cloud.resource where api.name = 'x' AND json.rule = tags[*.key exists`, `unbalanced "[" in json.rule`},
+		{`config from cloud.resource where api.name = 'x' AND json.rule = tags[*).key exists`, `")" does not match "[" in json.rule`},
+		{`config from cloud.resource where api.name = 'x' AND json.rule = tags].key exists`, `unbalanced "]" in json.rule`},
+		{`config from cloud.resource where api.name = 'x' AND json.rule = a exists addcolumn`, "addcolumn needs at least one column"},
+
+		// Joins.
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y;`, "joined queries need a show clause"},
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y; show X; filter '$.X.id == $.Y.id'`, "filter must come before show"},
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y; filter $.X.id == $.Y.id; show X`, "expected a quoted expression after filter"},
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y; filter '$.X.id == $.Y.id'; show Z`, `show refers to "Z"`},
+		{`config from cloud.resource where api.name = 'a' as X; config from cloud.resource where api.name = 'b' as Y; show X; show Y`, "duplicate show clause"},
+		{`config from cloud.resource where api.name = 'a'; filter '$.X.id == 1'`, "filter needs the queries to be named with as"},
+		{`config from cloud.resource where api.name = 'a' as X; event from cloud.audit_logs where user = 'root' as Y; show X`, "joined queries must all be config from cloud.resource queries"},
+		{`config from cloud.resource where api.name = 'a' as X as Y`, "duplicate as clause"},
+		{`config from cloud.resource where api.name = 'a' as X; count(Y) less than 1`, `count refers to "Y"`},
+		{`config from cloud.resource where api.name = 'a' as X; count(X) fewer than 1`, `expected a comparison after count, found "fewer"`},
+		{`config from cloud.resource where api.name = 'a' as X; count(X) less than`, "expected a number after the count comparison"},
+		{`config from cloud.resource where api.name = 'a' as X; count(X) < 1; show X`, "count must come last"},
+		{`event from cloud.audit_logs where ip exists in`, "expected a value after in"},
+
+		// Network subqueries.
+		{`network from vpc.flow_record where dest.resource IN ( resource where role IN ( 'AWS NAT Gateway' )`, `unbalanced "("`},
+		{`network from vpc.flow_record where dest.resource IN ( resource where )`, `expected a field name, found ")"`},
+	}
+
+	for _, tc := range cases {
+		_, err := parseRql(tc.query)
+		if err == nil {
+			t.Errorf("%s: no error, expected %q", tc.query, tc.err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %q, expected %q", tc.query, err, tc.err)
+		}
+	}
+}
+
+func TestRqlErrorColumn(t *testing.T) {
+	_, err := parseRql(`config from cloud.resource wher cloud.type = 'aws'`)
+	if err == nil {
+		t.Fatalf("No error")
+	}
+	if !strings.HasPrefix(err.Error(), "RQL syntax error at column 28:") {
+		t.Fatalf("Got %q", err)
+	}
+}
+
+func TestCheckRqlSearchType(t *testing.T) {
+	cases := []struct {
+		query, searchType string
+		ok                bool
+	}{
+		{`config from cloud.resource where cloud.type = 'aws'`, "config", true},
+		{`config from cloud.resource where cloud.type = 'aws'`, "event", false},
+		{`config from iam where dest.cloud.type = 'AWS'`, "iam", true},
+		{`config from iam where dest.cloud.type = 'AWS'`, "config", false},
+		{`event from cloud.audit_logs where user = 'root'`, "event", true},
+		{`network from vpc.flow_record where bytes > 0`, "network", true},
+		{`network from vpc.flow_record where bytes > 0`, "config", false},
+		{`asset where asset.class = 'Compute'`, "asset", true},
+		{`asset where asset.class = 'Compute'`, "config", false},
+		// Syntax errors are reported by validateRql instead.
+		{`config from`, "event", true},
+		// Only the head of the query is needed.
+		{`config from cloud.resource where api.name = 'x' as X; count(X) less than 1`, "config", true},
+		{`event from cloud.audit_logs where ip EXISTS IN network.list_name`, "event", true},
+		{`config from cloud.resource where something the linter does not know`, "event", false},
+	}
+
+	for _, tc := range cases {
+		err := checkRqlSearchType(tc.query, tc.searchType)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s as %s: got error %v", tc.query, tc.searchType, err)
+		}
+	}
+}
+
+func TestCheckRqlRuleType(t *testing.T) {
+	cases := []struct {
+		criteria, ruleType string
+		ok                 bool
+	}{
+		{"11111111-2222-3333-4444-555555555555", "Config", true},
+		{`{"label":"x"}`, "Network", true},
+		{`config from cloud.resource where cloud.type = 'aws'`, "Config", true},
+		{`config from iam where dest.cloud.type = 'AWS'`, "IAM", true},
+		{`config from iam where dest.cloud.type = 'AWS'`, "Config", false},
+		{`event from cloud.audit_logs where user = 'root'`, "AuditEvent", true},
+		{`event from cloud.audit_logs where user = 'root'`, "Config", false},
+		{`network from vpc.flow_record where bytes > 0`, "Network", true},
+		{`config from network where source.network = INTERNET`, "NetworkConfig", true},
+		{`config from cloud.resource where cloud.type = 'aws'`, "NetworkConfig", false},
+		{`config from cloud.resource where cloud.type = 'aws'`, "Anomaly", true},
+		{`config from cloud.resource where api.name = 'x' as X; show X; count(X) less than 1`, "Config", true},
+		{`config from cloud.resource where something the linter does not know`, "AuditEvent", false},
+	}
+
+	for _, tc := range cases {
+		err := checkRqlRuleType(tc.criteria, tc.ruleType)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s as %s: got error %v", tc.criteria, tc.ruleType, err)
+		}
+	}
+}
+
+func TestValidateRqlCriteria(t *testing.T) {
+	for _, v := range []string{"", "11111111-2222-3333-4444-555555555555", `{"a": 1}`, "config from cloud.resource where cloud.type = 'aws'"} {
+		if _, errs := validateRqlCriteria(v, "criteria"); len(errs) != 0 {
+			t.Errorf("%q: %v", v, errs)
+		}
+	}
+
+	if _, errs := validateRqlCriteria("config from cloud.resources where cloud.type = 'aws'", "criteria"); len(errs) != 1 {
+		t.Errorf("Expected an error, got %v", errs)
+	}
+}
+
+func TestValidateRql(t *testing.T) {
+	cases := []struct {
+		query    string
+		warnings int
+		errors   int
+	}{
+		{`config from cloud.resource where api.name = 'x' as X; count(X) less than 1`, 0, 0},
+		{`config from cloud.resource where api.name = 'x' as X; show X; count(X) less than 1`, 0, 0},
+		{`event from cloud.audit_logs where ip EXISTS IN network.list_name`, 0, 0},
+		// Past the head, what the linter does not understand is a warning.
+		{`config from cloud.resource where`, 1, 0},
+		{`config from cloud.resource where api.name = 'x' some future syntax`, 1, 0},
+		{`config from cloud.resources where cloud.type = 'aws'`, 0, 1},
+		{`select * from cloud.resource`, 0, 1},
+		{``, 0, 1},
+	}
+
+	for _, tc := range cases {
+		warnings, errs := validateRql(tc.query, "rql")
+		if len(warnings) != tc.warnings || len(errs) != tc.errors {
+			t.Errorf("%q: got warnings %v and errors %v, expected %d and %d", tc.query, warnings, errs, tc.warnings, tc.errors)
+		}
+	}
+}
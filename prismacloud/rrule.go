@@ -0,0 +1,797 @@
+package prismacloud
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Time zones are checked against the IANA database built into the
+	// provider, so that results don't depend on the host.
+	_ "time/tzdata"
+
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+)
+
+/*
+Notification and report schedules are RFC 5545 recurrence rules, optionally
+preceded by a DTSTART line, such as:
+
+	DTSTART;TZID=America/New_York:20240101T090000
+	RRULE:FREQ=WEEKLY;BYDAY=MO,TH
+
+The parser covers the RRULE grammar of RFC 5545 section 3.3.10, and the
+expansion covers what Prisma Cloud schedules use it for: working out when the
+next notifications will be sent.
+*/
+
+// rruleNextRuns is the number of upcoming fire times that are computed.
+const rruleNextRuns = 5
+
+// rruleMaxPeriods bounds the expansion of rules that rarely or never fire,
+// such as FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30.
+const rruleMaxPeriods = 5000
+
+// RRULE frequencies.
+const (
+	rruleSecondly = "SECONDLY"
+	rruleMinutely = "MINUTELY"
+	rruleHourly   = "HOURLY"
+	rruleDaily    = "DAILY"
+	rruleWeekly   = "WEEKLY"
+	rruleMonthly  = "MONTHLY"
+	rruleYearly   = "YEARLY"
+)
+
+var rruleFreqs = []string{rruleSecondly, rruleMinutely, rruleHourly, rruleDaily, rruleWeekly, rruleMonthly, rruleYearly}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// rruleWeekday is a BYDAY value, such as MO or -1FR.
+type rruleWeekday struct {
+	N   int
+	Day time.Weekday
+}
+
+// rrule is a parsed recurrence rule.
+type rrule struct {
+	Dtstart    time.Time
+	HasDtstart bool
+	Location   *time.Location
+
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	BySecond   []int
+	ByMinute   []int
+	ByHour     []int
+	ByDay      []rruleWeekday
+	ByMonthDay []int
+	ByYearDay  []int
+	ByWeekNo   []int
+	ByMonth    []int
+	BySetPos   []int
+	Wkst       time.Weekday
+}
+
+// parseRrule parses a recurrence rule with an optional DTSTART.  Without a
+// DTSTART, the rule starts at the given time, in its location.
+func parseRrule(s string, start time.Time) (*rrule, error) {
+	ans := &rrule{
+		Location: time.UTC,
+		Interval: 1,
+		Wkst:     time.Monday,
+	}
+
+	var ruleLine string
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			// A bare rule, without the RRULE: prefix.
+			name, value = "RRULE", line
+		}
+		params := strings.Split(name, ";")
+
+		switch strings.ToUpper(params[0]) {
+		case "DTSTART":
+			if ans.HasDtstart {
+				return nil, fmt.Errorf("DTSTART is given more than once")
+			}
+			if err := ans.parseDtstart(params[1:], value); err != nil {
+				return nil, err
+			}
+		case "RRULE":
+			if ruleLine != "" {
+				return nil, fmt.Errorf("only one RRULE is supported")
+			}
+			if value == "" {
+				return nil, fmt.Errorf("RRULE is empty")
+			}
+			ruleLine = value
+		default:
+			return nil, fmt.Errorf("unsupported property %q, expected DTSTART or RRULE", params[0])
+		}
+	}
+
+	if ruleLine == "" {
+		return nil, fmt.Errorf("no RRULE given")
+	}
+
+	if !ans.HasDtstart {
+		ans.Dtstart = start
+		ans.Location = start.Location()
+	}
+
+	if err := ans.parseRule(ruleLine); err != nil {
+		return nil, err
+	}
+
+	return ans, nil
+}
+
+func (r *rrule) parseDtstart(params []string, value string) error {
+	dateOnly := false
+	for _, p := range params {
+		key, v, _ := strings.Cut(p, "=")
+		switch strings.ToUpper(key) {
+		case "TZID":
+			loc, err := loadIanaLocation(v)
+			if err != nil {
+				return fmt.Errorf("DTSTART: %s", err)
+			}
+			r.Location = loc
+		case "VALUE":
+			switch strings.ToUpper(v) {
+			case "DATE":
+				dateOnly = true
+			case "DATE-TIME":
+			default:
+				return fmt.Errorf("DTSTART: unsupported VALUE %q", v)
+			}
+		default:
+			return fmt.Errorf("DTSTART: unsupported parameter %q", key)
+		}
+	}
+
+	t, err := parseRruleTime(value, r.Location, dateOnly)
+	if err != nil {
+		return fmt.Errorf("DTSTART: %s", err)
+	}
+	if strings.HasSuffix(value, "Z") && r.Location != time.UTC {
+		return fmt.Errorf("DTSTART: %q is UTC, but TZID %s is given", value, r.Location)
+	}
+
+	r.Dtstart = t
+	r.HasDtstart = true
+	return nil
+}
+
+// loadIanaLocation returns the IANA time zone of the given name.
+func loadIanaLocation(name string) (*time.Location, error) {
+	// LoadLocation also takes "" and "Local", which aren't zone names.
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("%q is not an IANA time zone", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an IANA time zone, such as \"America/New_York\"", name)
+	}
+
+	return loc, nil
+}
+
+// rruleStart returns the start of a rule without a DTSTART: the given hour
+// of the day in the given time zone.  An empty or unknown time zone is UTC.
+func rruleStart(now time.Time, tz string, hour int) time.Time {
+	loc := time.UTC
+	if tz != "" {
+		if v, err := loadIanaLocation(tz); err == nil {
+			loc = v
+		}
+	}
+
+	y, m, d := now.In(loc).Date()
+	return time.Date(y, m, d, hour, 0, 0, 0, loc)
+}
+
+// validateIanaTimezone is a ValidateFunc for IANA time zone names.
+func validateIanaTimezone(v interface{}, k string) ([]string, []error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected %q to be a string", k)}
+	}
+	if s == "" {
+		return nil, nil
+	}
+
+	if _, err := loadIanaLocation(s); err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", k, err)}
+	}
+
+	return nil, nil
+}
+
+// parseRruleTime parses an RFC 5545 DATE or DATE-TIME.
+func parseRruleTime(v string, loc *time.Location, dateOnly bool) (time.Time, error) {
+	if dateOnly || len(v) == 8 {
+		t, err := time.ParseInLocation("20060102", v, loc)
+		if err != nil {
+			return t, fmt.Errorf("%q is not a date of the form YYYYMMDD", v)
+		}
+		return t, nil
+	}
+
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse("20060102T150405Z", v)
+		if err != nil {
+			return t, fmt.Errorf("%q is not a date-time of the form YYYYMMDDTHHMMSS[Z]", v)
+		}
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	if err != nil {
+		return t, fmt.Errorf("%q is not a date-time of the form YYYYMMDDTHHMMSS[Z]", v)
+	}
+	return t, nil
+}
+
+func (r *rrule) parseRule(s string) error {
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, found := strings.Cut(part, "=")
+		key = strings.ToUpper(key)
+		if !found || value == "" {
+			return fmt.Errorf("RRULE part %q has no value", part)
+		}
+		if seen[key] {
+			return fmt.Errorf("RRULE part %s is given more than once", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if !stringInSlice(r.Freq, rruleFreqs) {
+				err = fmt.Errorf("FREQ must be one of %s, not %q", strings.Join(rruleFreqs, ", "), value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parseRruleInt(key, value, 1, 0)
+		case "COUNT":
+			r.Count, err = parseRruleInt(key, value, 1, 0)
+		case "UNTIL":
+			r.Until, err = parseRruleTime(value, r.Location, false)
+			if err != nil {
+				err = fmt.Errorf("UNTIL: %s", err)
+			}
+		case "BYSECOND":
+			r.BySecond, err = parseRruleInts(key, value, 0, 60, false)
+		case "BYMINUTE":
+			r.ByMinute, err = parseRruleInts(key, value, 0, 59, false)
+		case "BYHOUR":
+			r.ByHour, err = parseRruleInts(key, value, 0, 23, false)
+		case "BYDAY":
+			r.ByDay, err = parseRruleWeekdays(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRruleInts(key, value, 1, 31, true)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseRruleInts(key, value, 1, 366, true)
+		case "BYWEEKNO":
+			r.ByWeekNo, err = parseRruleInts(key, value, 1, 53, true)
+		case "BYMONTH":
+			r.ByMonth, err = parseRruleInts(key, value, 1, 12, false)
+		case "BYSETPOS":
+			r.BySetPos, err = parseRruleInts(key, value, 1, 366, true)
+		case "WKST":
+			day, ok := rruleWeekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("WKST must be one of MO, TU, WE, TH, FR, SA or SU, not %q", value)
+			}
+			r.Wkst = day
+		default:
+			err = fmt.Errorf("unknown RRULE part %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.check()
+}
+
+// check checks the rule parts that depend on each other.  UNTIL is only
+// checked against a given DTSTART, as the default start moves with the day
+// and would make a rule that has run its course invalid.
+func (r *rrule) check() error {
+	switch {
+	case r.Freq == "":
+		return fmt.Errorf("FREQ is required")
+	case r.Count != 0 && !r.Until.IsZero():
+		return fmt.Errorf("COUNT and UNTIL cannot both be given")
+	case r.HasDtstart && !r.Until.IsZero() && r.Until.Before(r.Dtstart):
+		return fmt.Errorf("UNTIL is before DTSTART")
+	case len(r.ByWeekNo) != 0 && r.Freq != rruleYearly:
+		return fmt.Errorf("BYWEEKNO can only be used with FREQ=YEARLY")
+	case len(r.ByYearDay) != 0 && (r.Freq == rruleDaily || r.Freq == rruleWeekly || r.Freq == rruleMonthly):
+		return fmt.Errorf("BYYEARDAY cannot be used with FREQ=%s", r.Freq)
+	case len(r.ByMonthDay) != 0 && r.Freq == rruleWeekly:
+		return fmt.Errorf("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	case len(r.BySetPos) != 0 && !r.hasByRule():
+		return fmt.Errorf("BYSETPOS needs another BYxxx rule part")
+	}
+
+	for _, wd := range r.ByDay {
+		if wd.N == 0 {
+			continue
+		}
+		switch {
+		case r.Freq != rruleMonthly && r.Freq != rruleYearly:
+			return fmt.Errorf("BYDAY cannot have a numeric prefix with FREQ=%s", r.Freq)
+		case r.Freq == rruleYearly && len(r.ByWeekNo) != 0:
+			return fmt.Errorf("BYDAY cannot have a numeric prefix with BYWEEKNO")
+		case r.Freq == rruleMonthly && (wd.N > 5 || wd.N < -5):
+			return fmt.Errorf("BYDAY prefix %d is out of range for FREQ=MONTHLY", wd.N)
+		}
+	}
+
+	return nil
+}
+
+func (r *rrule) hasByRule() bool {
+	return len(r.BySecond)+len(r.ByMinute)+len(r.ByHour)+len(r.ByDay)+len(r.ByMonthDay)+
+		len(r.ByYearDay)+len(r.ByWeekNo)+len(r.ByMonth) != 0
+}
+
+func parseRruleInt(key, v string, min, max int) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || (max != 0 && n > max) {
+		if max == 0 {
+			return 0, fmt.Errorf("%s must be an integer of at least %d, not %q", key, min, v)
+		}
+		return 0, fmt.Errorf("%s must be an integer from %d to %d, not %q", key, min, max, v)
+	}
+	return n, nil
+}
+
+// parseRruleInts parses a list of integers from min to max, or from -max to
+// -min if negative values are allowed.
+func parseRruleInts(key, v string, min, max int, negative bool) ([]int, error) {
+	parts := strings.Split(v, ",")
+	ans := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(strings.TrimPrefix(p, "+"))
+		abs := n
+		if negative && n < 0 {
+			abs = -n
+		}
+		if err != nil || abs < min || abs > max {
+			if negative {
+				return nil, fmt.Errorf("%s values must be from %d to %d or -%d to -%d, not %q", key, min, max, max, min, p)
+			}
+			return nil, fmt.Errorf("%s values must be from %d to %d, not %q", key, min, max, p)
+		}
+		ans = append(ans, n)
+	}
+	return ans, nil
+}
+
+func parseRruleWeekdays(v string) ([]rruleWeekday, error) {
+	parts := strings.Split(v, ",")
+	ans := make([]rruleWeekday, 0, len(parts))
+	for _, p := range parts {
+		p = strings.ToUpper(p)
+		if len(p) < 2 {
+			return nil, fmt.Errorf("BYDAY value %q is not a weekday, such as MO or -1FR", p)
+		}
+		day, ok := rruleWeekdays[p[len(p)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("BYDAY value %q is not a weekday, such as MO or -1FR", p)
+		}
+
+		var n int
+		if prefix := p[:len(p)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+			if err != nil || n == 0 || n > 53 || n < -53 {
+				return nil, fmt.Errorf("BYDAY value %q has an invalid prefix, expected 1 to 53 or -53 to -1", p)
+			}
+		}
+		ans = append(ans, rruleWeekday{N: n, Day: day})
+	}
+	return ans, nil
+}
+
+// Frequency returns the legacy notification frequency of the rule, or an
+// empty string if there is none.
+func (r *rrule) Frequency() string {
+	switch r.Freq {
+	case rruleDaily:
+		return rule.FrequencyDaily
+	case rruleWeekly:
+		return rule.FrequencyWeekly
+	case rruleMonthly:
+		return rule.FrequencyMonthly
+	}
+	return ""
+}
+
+// DaysOfMonth returns the days of the month a monthly rule fires on.
+func (r *rrule) DaysOfMonth() []int {
+	if r.Freq != rruleMonthly || len(r.ByDay) != 0 || len(r.BySetPos) != 0 {
+		return nil
+	}
+	if len(r.ByMonthDay) != 0 {
+		return r.ByMonthDay
+	}
+	return []int{r.Dtstart.In(r.Location).Day()}
+}
+
+// Next returns up to n times after the given time that the rule fires at.
+func (r *rrule) Next(after time.Time, n int) []time.Time {
+	ans := make([]time.Time, 0, n)
+	start := r.Dtstart.In(r.Location)
+
+	// With a COUNT, occurrences are counted from the start.
+	first := 0
+	if r.Count == 0 {
+		first = max(0, r.periodsBetween(start, after.In(r.Location))/r.Interval-1)
+	}
+
+	var count int
+	for k := first; k < first+rruleMaxPeriods; k++ {
+		list, ok := r.occurrences(start, k)
+		if !ok {
+			break
+		}
+		for _, t := range list {
+			if t.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return ans
+			}
+			count++
+			if r.Count != 0 && count > r.Count {
+				return ans
+			}
+			if t.After(after) {
+				ans = append(ans, t)
+				if len(ans) == n {
+					return ans
+				}
+			}
+		}
+	}
+
+	return ans
+}
+
+// periodsBetween returns the number of whole FREQ periods from a to b.
+func (r *rrule) periodsBetween(a, b time.Time) int {
+	if !b.After(a) {
+		return 0
+	}
+
+	switch r.Freq {
+	case rruleYearly:
+		return b.Year() - a.Year()
+	case rruleMonthly:
+		return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+	case rruleWeekly:
+		return civilDays(a, b) / 7
+	case rruleDaily:
+		return civilDays(a, b)
+	case rruleHourly:
+		return int(b.Sub(a) / time.Hour)
+	case rruleMinutely:
+		return int(b.Sub(a) / time.Minute)
+	}
+	return int(b.Sub(a) / time.Second)
+}
+
+// civilDays returns the number of calendar days from a to b.
+func civilDays(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// occurrences returns the sorted times the rule fires at in its k-th period.
+func (r *rrule) occurrences(start time.Time, k int) ([]time.Time, bool) {
+	step := k * r.Interval
+	loc := r.Location
+
+	var days []time.Time
+	switch r.Freq {
+	case rruleYearly:
+		y := start.Year() + step
+		if y > 9999 {
+			return nil, false
+		}
+		for d := time.Date(y, 1, 1, 0, 0, 0, 0, loc); d.Year() == y; d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	case rruleMonthly:
+		m := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, loc)
+		if m.Year() > 9999 {
+			return nil, false
+		}
+		for d := m; d.Month() == m.Month(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	case rruleWeekly:
+		offset := (int(start.Weekday()) - int(r.Wkst) + 7) % 7
+		ws := time.Date(start.Year(), start.Month(), start.Day()-offset+7*step, 0, 0, 0, 0, loc)
+		for i := 0; i < 7; i++ {
+			days = append(days, ws.AddDate(0, 0, i))
+		}
+	case rruleDaily:
+		days = append(days, time.Date(start.Year(), start.Month(), start.Day()+step, 0, 0, 0, 0, loc))
+	default:
+		return r.subDailyOccurrences(start, step), true
+	}
+
+	var ans []time.Time
+	for _, d := range days {
+		if !r.matchDay(d, start, true) {
+			continue
+		}
+		for _, h := range orDefault(r.ByHour, start.Hour()) {
+			for _, m := range orDefault(r.ByMinute, start.Minute()) {
+				for _, s := range orDefault(r.BySecond, start.Second()) {
+					ans = append(ans, time.Date(d.Year(), d.Month(), d.Day(), h, m, s, 0, loc))
+				}
+			}
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Before(ans[j]) })
+
+	return r.setPos(ans), true
+}
+
+// subDailyOccurrences returns the times in the step-th hour, minute or second
+// from the start.
+func (r *rrule) subDailyOccurrences(start time.Time, step int) []time.Time {
+	var t time.Time
+	switch r.Freq {
+	case rruleHourly:
+		t = start.Add(time.Duration(step) * time.Hour)
+	case rruleMinutely:
+		t = start.Add(time.Duration(step) * time.Minute)
+	default:
+		t = start.Add(time.Duration(step) * time.Second)
+	}
+
+	if !r.matchDay(t, start, false) || !intInList(t.Hour(), r.ByHour) {
+		return nil
+	}
+
+	minutes := []int{t.Minute()}
+	seconds := []int{t.Second()}
+	switch r.Freq {
+	case rruleHourly:
+		minutes = orDefault(r.ByMinute, start.Minute())
+		seconds = orDefault(r.BySecond, start.Second())
+	case rruleMinutely:
+		seconds = orDefault(r.BySecond, start.Second())
+	}
+
+	var ans []time.Time
+	for _, m := range minutes {
+		if !intInList(m, r.ByMinute) {
+			continue
+		}
+		for _, s := range seconds {
+			if !intInList(s, r.BySecond) {
+				continue
+			}
+			ans = append(ans, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), m, s, 0, r.Location))
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Before(ans[j]) })
+
+	return r.setPos(ans)
+}
+
+// matchDay returns if the rule fires on the given day.  For weekly, monthly
+// and yearly rules without day parts, the day of the start is used.
+func (r *rrule) matchDay(d, start time.Time, defaults bool) bool {
+	if len(r.ByMonth) != 0 && !intInList(int(d.Month()), r.ByMonth) {
+		return false
+	}
+
+	dim := daysIn(d.Year(), d.Month())
+	diy := time.Date(d.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+
+	if len(r.ByWeekNo) != 0 {
+		_, week := d.ISOWeek()
+		_, weeks := time.Date(d.Year(), 12, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+		if !matchOrdinal(week, weeks, r.ByWeekNo) {
+			return false
+		}
+	}
+	if len(r.ByYearDay) != 0 && !matchOrdinal(d.YearDay(), diy, r.ByYearDay) {
+		return false
+	}
+	if len(r.ByMonthDay) != 0 && !matchOrdinal(d.Day(), dim, r.ByMonthDay) {
+		return false
+	}
+	if len(r.ByDay) != 0 {
+		ok := false
+		for _, wd := range r.ByDay {
+			if wd.Day != d.Weekday() {
+				continue
+			}
+			if wd.N == 0 {
+				ok = true
+				break
+			}
+
+			// The n-th weekday of the month, or of the year.
+			pos, last := (d.Day()-1)/7+1, (dim-d.Day())/7+1
+			if r.Freq == rruleYearly && len(r.ByMonth) == 0 {
+				pos, last = (d.YearDay()-1)/7+1, (diy-d.YearDay())/7+1
+			}
+			if (wd.N > 0 && pos == wd.N) || (wd.N < 0 && last == -wd.N) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if !defaults || len(r.ByWeekNo)+len(r.ByYearDay)+len(r.ByMonthDay)+len(r.ByDay) != 0 {
+		return true
+	}
+
+	switch r.Freq {
+	case rruleWeekly:
+		return d.Weekday() == start.Weekday()
+	case rruleMonthly:
+		return d.Day() == start.Day()
+	case rruleYearly:
+		if len(r.ByMonth) == 0 && d.Month() != start.Month() {
+			return false
+		}
+		return d.Day() == start.Day()
+	}
+
+	return true
+}
+
+// setPos picks the BYSETPOS positions of a period's occurrences.
+func (r *rrule) setPos(list []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(list) == 0 {
+		return list
+	}
+
+	picked := make(map[int]bool)
+	for _, n := range r.BySetPos {
+		i := n - 1
+		if n < 0 {
+			i = len(list) + n
+		}
+		if i >= 0 && i < len(list) {
+			picked[i] = true
+		}
+	}
+
+	ans := make([]time.Time, 0, len(picked))
+	for i, t := range list {
+		if picked[i] {
+			ans = append(ans, t)
+		}
+	}
+	return ans
+}
+
+// matchOrdinal returns if v (from 1 to n) is in a list of positions, where
+// negative positions count from the end.
+func matchOrdinal(v, n int, list []int) bool {
+	for _, x := range list {
+		if x == v || (x < 0 && n+x+1 == v) {
+			return true
+		}
+	}
+	return false
+}
+
+func intInList(v int, list []int) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func orDefault(list []int, v int) []int {
+	if len(list) != 0 {
+		return list
+	}
+	return []int{v}
+}
+
+// validateRrule is a ValidateFunc for params that hold a recurrence rule.
+func validateRrule(v interface{}, k string) ([]string, []error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected %q to be a string", k)}
+	}
+	if s == "" {
+		return nil, nil
+	}
+
+	if _, err := parseRrule(s, rruleStart(time.Now(), "", 0)); err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", k, err)}
+	}
+
+	return nil, nil
+}
+
+// rruleNextRunTimes returns the next fire times of a rule in RFC3339, or nil
+// if the rule is empty or invalid.  A rule without a DTSTART starts today at
+// the given hour in the given time zone.
+func rruleNextRunTimes(s, tz string, hour int, now time.Time) []string {
+	if s == "" {
+		return nil
+	}
+
+	r, err := parseRrule(s, rruleStart(now, tz, hour))
+	if err != nil {
+		return nil
+	}
+
+	list := r.Next(now, rruleNextRuns)
+	ans := make([]string, 0, len(list))
+	for _, t := range list {
+		ans = append(ans, t.Format(time.RFC3339))
+	}
+	return ans
+}
+
+// rruleFrequency returns the legacy frequency of a rule, or the fallback if
+// the rule has none.
+func rruleFrequency(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+
+	r, err := parseRrule(s, rruleStart(time.Now(), "", 0))
+	if err != nil || r.Frequency() == "" {
+		return fallback
+	}
+
+	return r.Frequency()
+}
@@ -0,0 +1,300 @@
+package prismacloud
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+)
+
+func TestParseRruleErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY", ""},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,TH;BYHOUR=9", ""},
+		{"DTSTART;TZID=America/New_York:20240101T090000\nRRULE:FREQ=MONTHLY;BYDAY=-1FR", ""},
+		{"DTSTART:20240101T090000Z\nRRULE:FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO", ""},
+		{"DTSTART;VALUE=DATE:20240101\nRRULE:FREQ=DAILY;COUNT=3", ""},
+		{"", "no RRULE given"},
+		{"RRULE:INTERVAL=2", "FREQ is required"},
+		{"RRULE:FREQ=FORTNIGHTLY", "FREQ must be one of"},
+		{"RRULE:FREQ=DAILY;FREQ=WEEKLY", "FREQ is given more than once"},
+		{"RRULE:FREQ=DAILY;INTERVAL=0", "INTERVAL must be an integer of at least 1"},
+		{"RRULE:FREQ=DAILY;COUNT=2;UNTIL=20300101T000000Z", "COUNT and UNTIL cannot both be given"},
+		{"RRULE:FREQ=DAILY;BYHOUR=24", "BYHOUR values must be from 0 to 23"},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=0", "BYMONTHDAY values must be from 1 to 31 or -31 to -1"},
+		{"RRULE:FREQ=WEEKLY;BYMONTHDAY=1", "BYMONTHDAY cannot be used with FREQ=WEEKLY"},
+		{"RRULE:FREQ=MONTHLY;BYWEEKNO=1", "BYWEEKNO can only be used with FREQ=YEARLY"},
+		{"RRULE:FREQ=WEEKLY;BYDAY=1MO", "BYDAY cannot have a numeric prefix with FREQ=WEEKLY"},
+		{"RRULE:FREQ=MONTHLY;BYDAY=XX", "BYDAY value \"XX\" is not a weekday"},
+		{"RRULE:FREQ=DAILY;BYSETPOS=1", "BYSETPOS needs another BYxxx rule part"},
+		{"RRULE:FREQ=DAILY;FOO=1", "unknown RRULE part \"FOO\""},
+		{"RRULE:FREQ=DAILY;WKST=XX", "WKST must be one of"},
+		{"EXDATE:20240101T000000Z\nRRULE:FREQ=DAILY", "unsupported property \"EXDATE\""},
+		{"DTSTART;TZID=Mars/Olympus_Mons:20240101T090000\nRRULE:FREQ=DAILY", "\"Mars/Olympus_Mons\" is not an IANA time zone"},
+		{"DTSTART;TZID=Local:20240101T090000\nRRULE:FREQ=DAILY", "\"Local\" is not an IANA time zone"},
+		{"DTSTART;TZID=America/New_York:20240101T090000Z\nRRULE:FREQ=DAILY", "is UTC, but TZID America/New_York is given"},
+		{"DTSTART:2024-01-01\nRRULE:FREQ=DAILY", "is not a date-time"},
+		{"DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY;UNTIL=20230101T000000Z", "UNTIL is before DTSTART"},
+		{"RRULE:FREQ=WEEKLY;UNTIL=20250101T000000Z", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.rule, func(t *testing.T) {
+			_, err := parseRrule(tc.rule, time.Now())
+			switch {
+			case tc.want == "" && err != nil:
+				t.Errorf("Unexpected error: %s", err)
+			case tc.want != "" && err == nil:
+				t.Errorf("Expected an error containing %q", tc.want)
+			case tc.want != "" && !strings.Contains(err.Error(), tc.want):
+				t.Errorf("Got error %q, expected it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRruleNext(t *testing.T) {
+	after := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		rule string
+		want []string
+	}{
+		{
+			"daily without dtstart",
+			"FREQ=DAILY;BYHOUR=9",
+			[]string{"2024-03-07T09:00:00Z", "2024-03-08T09:00:00Z", "2024-03-09T09:00:00Z"},
+		},
+		{
+			"weekly in a time zone across dst",
+			"DTSTART;TZID=America/New_York:20240101T090000\nRRULE:FREQ=WEEKLY;BYDAY=MO",
+			[]string{"2024-03-11T09:00:00-04:00", "2024-03-18T09:00:00-04:00", "2024-03-25T09:00:00-04:00"},
+		},
+		{
+			"every other week",
+			"DTSTART:20240101T080000Z\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			[]string{"2024-03-11T08:00:00Z", "2024-03-15T08:00:00Z", "2024-03-25T08:00:00Z"},
+		},
+		{
+			"monthly on the start day",
+			"DTSTART:20240115T000000Z\nRRULE:FREQ=MONTHLY",
+			[]string{"2024-03-15T00:00:00Z", "2024-04-15T00:00:00Z", "2024-05-15T00:00:00Z"},
+		},
+		{
+			"last friday of the month",
+			"DTSTART:20240101T170000Z\nRRULE:FREQ=MONTHLY;BYDAY=-1FR",
+			[]string{"2024-03-29T17:00:00Z", "2024-04-26T17:00:00Z", "2024-05-31T17:00:00Z"},
+		},
+		{
+			"last weekday of the month",
+			"DTSTART:20240101T170000Z\nRRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			[]string{"2024-03-29T17:00:00Z", "2024-04-30T17:00:00Z", "2024-05-31T17:00:00Z"},
+		},
+		{
+			"31st skips short months",
+			"DTSTART:20240131T000000Z\nRRULE:FREQ=MONTHLY;BYMONTHDAY=31",
+			[]string{"2024-03-31T00:00:00Z", "2024-05-31T00:00:00Z", "2024-07-31T00:00:00Z"},
+		},
+		{
+			"yearly leap day",
+			"DTSTART:20240229T000000Z\nRRULE:FREQ=YEARLY",
+			[]string{"2028-02-29T00:00:00Z", "2032-02-29T00:00:00Z", "2036-02-29T00:00:00Z"},
+		},
+		{
+			"count",
+			"DTSTART:20240305T000000Z\nRRULE:FREQ=DAILY;COUNT=4",
+			[]string{"2024-03-07T00:00:00Z", "2024-03-08T00:00:00Z"},
+		},
+		{
+			"until",
+			"DTSTART:20240101T000000Z\nRRULE:FREQ=DAILY;UNTIL=20240308T000000Z",
+			[]string{"2024-03-07T00:00:00Z", "2024-03-08T00:00:00Z"},
+		},
+		{
+			"hourly",
+			"DTSTART:20240101T000000Z\nRRULE:FREQ=HOURLY;INTERVAL=6;BYMINUTE=30",
+			[]string{"2024-03-06T12:30:00Z", "2024-03-06T18:30:00Z", "2024-03-07T00:30:00Z"},
+		},
+		{
+			"never",
+			"DTSTART:20240101T000000Z\nRRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			[]string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := parseRrule(tc.rule, after)
+			if err != nil {
+				t.Fatalf("Error parsing %q: %s", tc.rule, err)
+			}
+
+			got := make([]string, 0, 3)
+			for _, v := range r.Next(after, 3) {
+				got = append(got, v.Format(time.RFC3339))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Got %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRruleNextRunTimes(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		rule string
+		tz   string
+		hour int
+		want []string
+	}{
+		{
+			"utc midnight by default",
+			"FREQ=DAILY;COUNT=30",
+			"", 0,
+			[]string{"2024-03-07T00:00:00Z", "2024-03-08T00:00:00Z"},
+		},
+		{
+			"time zone and hour of day",
+			"FREQ=DAILY",
+			"America/New_York", 9,
+			[]string{"2024-03-06T09:00:00-05:00", "2024-03-07T09:00:00-05:00"},
+		},
+		{
+			"unknown time zone is utc",
+			"FREQ=DAILY",
+			"Mars/Olympus_Mons", 9,
+			[]string{"2024-03-07T09:00:00Z", "2024-03-08T09:00:00Z"},
+		},
+		{
+			"expired",
+			"FREQ=WEEKLY;UNTIL=20240101T000000Z",
+			"", 0,
+			[]string{},
+		},
+		{
+			"dtstart wins",
+			"DTSTART:20240101T060000Z\nRRULE:FREQ=DAILY",
+			"America/New_York", 9,
+			[]string{"2024-03-07T06:00:00Z", "2024-03-08T06:00:00Z"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rruleNextRunTimes(tc.rule, tc.tz, tc.hour, now)
+			if len(got) > 2 {
+				got = got[:2]
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Got %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateIanaTimezone(t *testing.T) {
+	cases := []struct {
+		tz string
+		ok bool
+	}{
+		{"", true},
+		{"UTC", true},
+		{"Asia/Kolkata", true},
+		{"Local", false},
+		{"EST5EDT+", false},
+		{"Mars/Olympus_Mons", false},
+	}
+
+	for _, tc := range cases {
+		_, errs := validateIanaTimezone(tc.tz, "timezone_id")
+		if ok := len(errs) == 0; ok != tc.ok {
+			t.Errorf("%q: got errors %v, expected ok to be %t", tc.tz, errs, tc.ok)
+		}
+	}
+}
+
+func TestRruleFrequency(t *testing.T) {
+	cases := []struct {
+		rule string
+		freq string
+		days []int
+	}{
+		{"FREQ=DAILY", "daily", nil},
+		{"FREQ=WEEKLY;BYDAY=MO", "weekly", nil},
+		{"DTSTART:20240115T000000Z\nRRULE:FREQ=MONTHLY", "monthly", []int{15}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,15", "monthly", []int{1, 15}},
+		{"FREQ=MONTHLY;BYDAY=1MO", "monthly", nil},
+		{"FREQ=HOURLY", "", nil},
+	}
+
+	for _, tc := range cases {
+		r, err := parseRrule(tc.rule, time.Now())
+		if err != nil {
+			t.Fatalf("Error parsing %q: %s", tc.rule, err)
+		}
+		if got := r.Frequency(); got != tc.freq {
+			t.Errorf("%q: frequency is %q, expected %q", tc.rule, got, tc.freq)
+		}
+		if got := r.DaysOfMonth(); !reflect.DeepEqual(got, tc.days) {
+			t.Errorf("%q: days of month are %v, expected %v", tc.rule, got, tc.days)
+		}
+	}
+}
+
+func TestCheckNotificationSchedule(t *testing.T) {
+	nc := func(rr, freq string, dom int) cty.Value {
+		m := map[string]cty.Value{
+			"r_rule_schedule": cty.StringVal(rr),
+			"frequency":       cty.NullVal(cty.String),
+			"day_of_month":    cty.NullVal(cty.Number),
+		}
+		if freq != "" {
+			m["frequency"] = cty.StringVal(freq)
+		}
+		if dom != 0 {
+			m["day_of_month"] = cty.NumberIntVal(int64(dom))
+		}
+		return cty.ObjectVal(m)
+	}
+
+	cases := []struct {
+		name string
+		nc   cty.Value
+		want string
+	}{
+		{"no rrule", nc("", "daily", 3), ""},
+		{"invalid rrule", nc("FREQ=NEVER", "daily", 0), ""},
+		{"matching frequency", nc("FREQ=WEEKLY;BYDAY=MO", "weekly", 0), ""},
+		{"frequency mismatch", nc("FREQ=WEEKLY;BYDAY=MO", "daily", 0), `frequency is "daily", but r_rule_schedule is FREQ=WEEKLY ("weekly")`},
+		{"as it happens", nc("FREQ=DAILY", "as_it_happens", 0), `frequency is "as_it_happens"`},
+		{"no legacy frequency", nc("FREQ=HOURLY", "daily", 0), "FREQ=HOURLY, which has no matching frequency"},
+		{"matching day", nc("FREQ=MONTHLY;BYMONTHDAY=15", "monthly", 15), ""},
+		{"matching dtstart day", nc("DTSTART:20240115T090000Z\nRRULE:FREQ=MONTHLY", "", 15), ""},
+		{"day mismatch", nc("FREQ=MONTHLY;BYMONTHDAY=1,15", "", 15), "fires on day 1, 15 of the month"},
+		{"day by weekday", nc("FREQ=MONTHLY;BYDAY=1MO", "", 1), "picks days of the month by weekday"},
+		{"day not monthly", nc("FREQ=WEEKLY", "", 1), "FREQ=WEEKLY, not FREQ=MONTHLY"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkNotificationSchedule(tc.nc)
+			switch {
+			case tc.want == "" && err != nil:
+				t.Errorf("Unexpected error: %s", err)
+			case tc.want != "" && err == nil:
+				t.Errorf("Expected an error containing %q", tc.want)
+			case tc.want != "" && !strings.Contains(err.Error(), tc.want):
+				t.Errorf("Got error %q, expected it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,18 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// nextRunsSchema is the schema for the upcoming fire times of an RRULE.
+func nextRunsSchema(desc string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: fmt.Sprintf("Next %d times %s, computed from the RRULE", rruleNextRuns, desc),
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+}
+
 func totalSchema(desc string) *schema.Schema {
 	return &schema.Schema{
 		Type:        schema.TypeInt,
@@ -162,7 +174,7 @@ func timeRangeSchema(style string) *schema.Schema {
 	}
 
 	switch style {
-	case "resource_report", "data_source_report", "data_source_rql_historic_search":
+	case "resource_report", "data_source_report", "data_source_rql_historic_search", "resource_policy":
 		// Commenting this out until the SDK allows for nested and
 		// relative ConflictsWith.
 		/*
@@ -0,0 +1,203 @@
+package prismacloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+
+	pc "github.com/paloaltonetworks/prisma-cloud-go"
+	"github.com/paloaltonetworks/prisma-cloud-go/alert/rule"
+	"github.com/paloaltonetworks/prisma-cloud-go/cloud/account/group"
+	"github.com/paloaltonetworks/prisma-cloud-go/compliance/standard"
+	"github.com/paloaltonetworks/prisma-cloud-go/compliance/standard/requirement"
+	"github.com/paloaltonetworks/prisma-cloud-go/compliance/standard/requirement/section"
+	"github.com/paloaltonetworks/prisma-cloud-go/data-security/datapattern"
+	"github.com/paloaltonetworks/prisma-cloud-go/data-security/dataprofile"
+	"github.com/paloaltonetworks/prisma-cloud-go/integration"
+	"github.com/paloaltonetworks/prisma-cloud-go/ip-address"
+	"github.com/paloaltonetworks/prisma-cloud-go/permission_group"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+	"github.com/paloaltonetworks/prisma-cloud-go/report"
+	"github.com/paloaltonetworks/prisma-cloud-go/user/role"
+)
+
+/*
+The SDK's Create functions for these objects throw away the response body,
+so the resources used to find what they had just created by polling
+Identify() with the name.  That races when two configs use the same name and
+costs a few list calls per resource.
+
+The SDK is a separate module (github.com/paloaltonetworks/prisma-cloud-go)
+that is only vendored here, and changing what its Create functions return
+would break its other users, so the provider does the POST itself.  The
+functions below send the same request as the SDK's createUpdate(), but decode
+and return the created object so that its ID can be used directly.  If the
+endpoint does not echo the object back, the returned object has an empty ID
+and the resource falls back to the name lookup.
+
+Objects whose ID is known before they are created (cloud accounts) or whose
+SDK Create already returns the response (trusted alert IPs, anomaly trusted
+lists) don't need a function here.
+*/
+
+// integrationTenantSuffix is the path prefix of integrations that need the
+// prisma ID, which the SDK does not export.
+var integrationTenantSuffix = []string{"api", "v1", "tenant"}
+
+// communicateCreate POSTs obj to path and decodes the response into ans.
+func communicateCreate(c pc.PrismaCloudClient, path []string, obj, ans interface{}) error {
+	body, err := c.Communicate("POST", path, nil, obj, nil)
+	if err != nil {
+		return err
+	}
+
+	decodeCreated(strings.Join(path, "/"), body, ans)
+	return nil
+}
+
+// decodeCreated decodes the response of a create call into ans, leaving ans
+// as is if the response is empty or not the object.
+func decodeCreated(path string, body []byte, ans interface{}) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return
+	}
+
+	if err := json.Unmarshal(body, ans); err != nil {
+		log.Printf("[WARN] Could not decode the object created at %q: %s", path, err)
+	}
+}
+
+func createPolicyObject(c pc.PrismaCloudClient, obj policy.Policy) (policy.Policy, error) {
+	c.Log(pc.LogAction, "(create) policy")
+
+	var ans policy.Policy
+	err := communicateCreate(c, policy.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createAlertRuleObject(c pc.PrismaCloudClient, obj rule.Rule) (rule.Rule, error) {
+	c.Log(pc.LogAction, "(create) alert rule")
+
+	var ans rule.Rule
+	err := communicateCreate(c, rule.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createAccountGroupObject(c pc.PrismaCloudClient, obj group.Group) (group.Group, error) {
+	c.Log(pc.LogAction, "(create) account group")
+
+	var ans group.Group
+	err := communicateCreate(c, group.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createComplianceStandardObject(c pc.PrismaCloudClient, obj standard.Standard) (standard.Standard, error) {
+	c.Log(pc.LogAction, "(create) compliance standard")
+
+	var ans standard.Standard
+	err := communicateCreate(c, standard.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createComplianceStandardRequirementObject(c pc.PrismaCloudClient, obj requirement.Requirement) (requirement.Requirement, error) {
+	c.Log(pc.LogAction, "(create) compliance standard requirement")
+
+	var ans requirement.Requirement
+	err := communicateCreate(c, requirement.ComplianceSuffix(obj.ComplianceId), obj, &ans)
+	return ans, err
+}
+
+func createComplianceStandardRequirementSectionObject(c pc.PrismaCloudClient, obj section.Section) (section.Section, error) {
+	c.Log(pc.LogAction, "(create) compliance standard requirement section")
+
+	var ans section.Section
+	err := communicateCreate(c, section.RequirementSuffix(obj.RequirementId), obj, &ans)
+	return ans, err
+}
+
+func createReportObject(c pc.PrismaCloudClient, obj report.Report) (report.Report, error) {
+	c.Log(pc.LogAction, "(create) report")
+
+	var ans report.Report
+	err := communicateCreate(c, report.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createPermissionGroupObject(c pc.PrismaCloudClient, obj permission_group.PermissionGroup) (permission_group.PermissionGroup, error) {
+	c.Log(pc.LogAction, "(create) permission group")
+
+	var ans permission_group.PermissionGroup
+	err := communicateCreate(c, permission_group.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createUserRoleObject(c pc.PrismaCloudClient, obj role.Role) (role.Role, error) {
+	c.Log(pc.LogAction, "(create) user role")
+
+	var ans role.Role
+	err := communicateCreate(c, role.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createTrustedLoginIpObject(c pc.PrismaCloudClient, obj ip_address.LoginIpAllow) (ip_address.LoginIpAllow, error) {
+	c.Log(pc.LogAction, "(create) trusted login ip")
+
+	var ans ip_address.LoginIpAllow
+	err := communicateCreate(c, ip_address.Suffix, obj, &ans)
+	return ans, err
+}
+
+func createDataPatternObject(c pc.PrismaCloudClient, obj datapattern.Pattern) (datapattern.Pattern, error) {
+	c.Log(pc.LogAction, "(create) data pattern")
+
+	var ans datapattern.Pattern
+	tenantId, err := datapattern.GetTenantId(c)
+	if err != nil {
+		return ans, err
+	}
+
+	path := make([]string, 0, len(datapattern.Suffix)+1)
+	path = append(path, datapattern.Suffix...)
+	path = append(path, tenantId)
+
+	err = communicateCreate(c, path, obj, &ans)
+	return ans, err
+}
+
+func createDataProfileObject(c pc.PrismaCloudClient, obj dataprofile.Profile) (dataprofile.Profile, error) {
+	c.Log(pc.LogAction, "(create) data profile")
+
+	var ans dataprofile.Profile
+	tenantId, err := dataprofile.GetTenantId(c)
+	if err != nil {
+		return ans, err
+	}
+
+	path := make([]string, 0, len(dataprofile.Suffix)+1)
+	path = append(path, dataprofile.Suffix...)
+	path = append(path, tenantId)
+
+	err = communicateCreate(c, path, obj, &ans)
+	return ans, err
+}
+
+func createIntegrationObject(c pc.PrismaCloudClient, obj integration.Integration, prismaIdRequired bool) (integration.Integration, error) {
+	c.Log(pc.LogAction, "(create) integration")
+
+	var ans integration.Integration
+	path := make([]string, 0, len(integrationTenantSuffix)+len(integration.Suffix)+1)
+	if prismaIdRequired {
+		prismaId, err := integration.GetPrismaId(c)
+		if err != nil {
+			return ans, err
+		}
+
+		path = append(path, integrationTenantSuffix...)
+		path = append(path, prismaId)
+	}
+	path = append(path, integration.Suffix...)
+
+	err := communicateCreate(c, path, obj, &ans)
+	return ans, err
+}
@@ -0,0 +1,68 @@
+package prismacloud
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paloaltonetworks/prisma-cloud-go/integration"
+	"github.com/paloaltonetworks/prisma-cloud-go/policy"
+)
+
+type fakeClient struct {
+	method string
+	path   []string
+	body   []byte
+}
+
+func (c *fakeClient) Initialize(string) error { return nil }
+func (c *fakeClient) Authenticate() error     { return nil }
+func (c *fakeClient) Log(string, string, ...interface{}) {
+}
+
+func (c *fakeClient) Communicate(method string, path []string, query, data, ans interface{}) ([]byte, error) {
+	c.method = method
+	c.path = path
+	return c.body, nil
+}
+
+func TestCreatePolicyObjectDecodesId(t *testing.T) {
+	c := &fakeClient{body: []byte(`{"policyId": "abc-123", "name": "foo"}`)}
+
+	ans, err := createPolicyObject(c, policy.Policy{Name: "foo"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.method != "POST" || len(c.path) != 1 || c.path[0] != "policy" {
+		t.Fatalf("Sent %s %v", c.method, c.path)
+	}
+	if ans.PolicyId != "abc-123" {
+		t.Fatalf("Policy ID is %q, expected %q", ans.PolicyId, "abc-123")
+	}
+}
+
+func TestCreatePolicyObjectEmptyBody(t *testing.T) {
+	c := &fakeClient{}
+
+	ans, err := createPolicyObject(c, policy.Policy{Name: "foo"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ans.PolicyId != "" {
+		t.Fatalf("Policy ID is %q, expected it to be empty", ans.PolicyId)
+	}
+}
+
+func TestCreateIntegrationObjectDecodesId(t *testing.T) {
+	c := &fakeClient{body: []byte(`{"id": "int-1", "name": "foo"}`)}
+
+	ans, err := createIntegrationObject(c, integration.Integration{Name: "foo"}, false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.method != "POST" || strings.Join(c.path, "/") != "integration" {
+		t.Fatalf("Sent %s %v", c.method, c.path)
+	}
+	if ans.Id != "int-1" {
+		t.Fatalf("Integration ID is %q, expected %q", ans.Id, "int-1")
+	}
+}